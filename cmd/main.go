@@ -1,20 +1,18 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"io"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/KarmaBeLike/time-tracker-api/config"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
-
-	postgres "github.com/KarmaBeLike/time-tracker-api/internal/database"
-	"github.com/KarmaBeLike/time-tracker-api/internal/external"
-	"github.com/KarmaBeLike/time-tracker-api/internal/handlers"
-	repositories "github.com/KarmaBeLike/time-tracker-api/internal/repository"
-	"github.com/KarmaBeLike/time-tracker-api/internal/service"
+	"github.com/KarmaBeLike/time-tracker-api/internal/app"
 	"github.com/KarmaBeLike/time-tracker-api/pkg/logger"
-	"github.com/gin-gonic/gin"
+	"github.com/spf13/pflag"
 )
 
 // @title Time Tracker API
@@ -28,50 +26,77 @@ import (
 // @license.url http://www.apache.org/licenses/LICENSE-2.0.html
 // @host localhost:8080
 // @BasePath /
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
+// @description "Bearer" followed by the ADMIN_TOKEN
 
 func main() {
-	logger.New(os.Stdout, logger.LevelDebug)
-
-	cfg, err := config.Load()
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, pflag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		logger.PrintError(err, nil)
+		logger.PrintFatal(err, nil)
 	}
 
-	db, err := postgres.OpenDB(cfg)
+	logFile, err := setupLogger(cfg)
 	if err != nil {
 		logger.PrintFatal(err, nil)
 	}
-	defer db.Close()
+	if logFile != nil {
+		defer logFile.Close()
+	}
+
+	logger.PrintInfo("Configuration loaded", cfg.Redacted())
 
-	// Выполнение миграций
-	if err := postgres.RunMigrations(db); err != nil {
+	// SIGHUP перечитывает секреты из файлов; подписываемся заранее, чтобы сигнал
+	// во время подключения к БД не завершил процесс
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	application, err := app.New(cfg)
+	if err != nil {
 		logger.PrintFatal(err, nil)
 	}
-	router := gin.Default()
-	url := ginSwagger.URL("http://localhost:8080/swagger/doc.json")
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, url))
 
-	router.Run()
+	go func() {
+		for range hup {
+			if err := application.ReloadSecrets(); err != nil {
+				logger.PrintError(err, map[string]any{"signal": "SIGHUP"})
+			}
+		}
+	}()
 
-	// Инициализация репозитория и сервиса
-	userRepo := repositories.NewUserRepository(db)
-	peopleAPIClient := external.NewPeopleAPIClient(cfg.PeopleAPIBaseURL)
-	userService := service.NewUserService(userRepo, peopleAPIClient)
-	userHandler := handlers.NewUserHandler(userService)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	taskRepo := repositories.NewTaskRepository(db)
-	taskService := service.NewTaskService(taskRepo)
-	taskHandler := handlers.NewTaskHandler(taskService)
+	if err := application.Run(ctx); err != nil {
+		logger.PrintFatal(err, nil)
+	}
+}
 
-	// Регистрация маршрутов
-	userHandler.Routes(router, cfg)
-	taskHandler.Routes(router, cfg)
+// setupLogger configures the package-level logger from cfg. When logs go to a
+// file, the file is returned so that it can be closed on exit.
+func setupLogger(cfg *config.Config) (io.Closer, error) {
+	level, err := logger.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+	opts := logger.Options{Level: level, Format: strings.ToLower(cfg.LogFormat), Stacktrace: cfg.LogStacktrace, Output: os.Stdout}
 
-	// Проверка доступности главной страницы
-	router.NoRoute(func(c *gin.Context) {
-		c.JSON(404, gin.H{"message": "Not Found"})
-	})
+	var file *logger.RotatingFile
+	if cfg.LogFile != "" {
+		file, err = logger.NewRotatingFile(cfg.LogFile, int64(cfg.LogMaxSizeMB)<<20, cfg.LogMaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		opts.Output = file
+	}
 
-	log.Println("Server is running at http://localhost:8080")
-	router.Run(":8080")
+	logger.Configure(opts)
+	if file == nil {
+		return nil, nil
+	}
+	return file, nil
 }
@@ -0,0 +1,172 @@
+// Command timetrackerctl is the admin tool of the time tracker. It works on
+// the database directly through the service and repository packages, using
+// the same configuration as the API server.
+//
+// Usage:
+//
+//	timetrackerctl [config flags] <command> <subcommand> [flags] [args]
+//
+// Run it without arguments for the list of commands.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/KarmaBeLike/time-tracker-api/config"
+	"github.com/KarmaBeLike/time-tracker-api/internal/clock"
+	postgres "github.com/KarmaBeLike/time-tracker-api/internal/database"
+	"github.com/KarmaBeLike/time-tracker-api/internal/external"
+	repositories "github.com/KarmaBeLike/time-tracker-api/internal/repository"
+	"github.com/KarmaBeLike/time-tracker-api/internal/service"
+	"github.com/KarmaBeLike/time-tracker-api/pkg/logger"
+	"github.com/spf13/pflag"
+)
+
+const name = "timetrackerctl"
+
+// command runs a subcommand with the arguments that follow it.
+type command struct {
+	usage string
+	run   func(ctx context.Context, c *cli, args []string) error
+}
+
+var commands = map[string]map[string]command{
+	"migrate": {
+		"up":     {"apply pending migrations", migrateUp},
+		"down":   {"roll back the last migrations (--steps, default 1)", migrateDown},
+		"status": {"list migrations and when they were applied", migrateStatus},
+	},
+	"users": {
+		"create": {"create a user from their passport number (--passport)", createUser},
+		"list":   {"list users (--search, --include-deleted, --page, --limit)", listUsers},
+		"delete": {"soft-delete a user by ID, or anonymise them with --purge", deleteUser},
+	},
+	"timers": {
+		"start": {"start a timer (--user, --task)", startTimer},
+		"stop":  {"stop a running timer (--user, --task)", stopTimer},
+		"sweep": {"stop timers that exceed their auto-stop policy", sweepTimers},
+	},
+	"report": {
+		"worklogs": {"export the time a user spent per task (--user, --from, --to, --format, --out)", exportWorklogs},
+	},
+}
+
+// cli holds the dependencies of the commands. They are created on first
+// use, so that e.g. "migrate up" doesn't need the People API.
+type cli struct {
+	cfg *config.Config
+	db  *sql.DB
+	out io.Writer
+}
+
+func main() {
+	// Сообщения журнала не смешиваются с выводом команд
+	logger.Configure(logger.Options{Level: logger.LevelInfo, Format: logger.FormatConsole, Output: os.Stderr})
+
+	if err := run(os.Args[1:]); err != nil {
+		if !errors.Is(err, pflag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, name+":", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	cfg, rest, err := config.LoadCommand(name, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) < 2 {
+		usage(os.Stderr)
+		return pflag.ErrHelp
+	}
+
+	cmd, ok := commands[rest[0]][rest[1]]
+	if !ok {
+		usage(os.Stderr)
+		return fmt.Errorf("unknown command %q", strings.Join(rest[:2], " "))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := postgres.OpenDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return cmd.run(ctx, &cli{cfg: cfg, db: db, out: os.Stdout}, rest[2:])
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s [config flags] <command> <subcommand> [flags] [args]\n\nCommands:\n", name)
+
+	groups := make([]string, 0, len(commands))
+	for group := range commands {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	for _, group := range groups {
+		subs := make([]string, 0, len(commands[group]))
+		for sub := range commands[group] {
+			subs = append(subs, sub)
+		}
+		sort.Strings(subs)
+		for _, sub := range subs {
+			fmt.Fprintf(w, "  %-18s %s\n", group+" "+sub, commands[group][sub].usage)
+		}
+	}
+	fmt.Fprintf(w, "\nConfig flags are those of the API server, e.g. --config or --db-host; see %s --help.\n", name)
+}
+
+func (c *cli) timeouts() repositories.Timeouts {
+	return repositories.Timeouts{Query: c.cfg.DBQueryTimeout, Report: c.cfg.DBReportTimeout}
+}
+
+func (c *cli) userService() (service.UserService, error) {
+	if err := c.cfg.ValidatePeople(); err != nil {
+		return nil, err
+	}
+	personProvider, err := external.NewPersonInfoProvider(c.cfg)
+	if err != nil {
+		return nil, err
+	}
+	return service.NewUserService(repositories.NewUserRepository(c.db, c.timeouts()), personProvider), nil
+}
+
+func (c *cli) taskRepo() repositories.TaskRepository {
+	return repositories.NewTaskRepository(c.db, c.timeouts())
+}
+
+func (c *cli) taskService() service.TaskService {
+	return service.NewTaskService(c.taskRepo(), clock.System)
+}
+
+// newFlags returns the flag set of a subcommand.
+func newFlags(command string) *pflag.FlagSet {
+	return pflag.NewFlagSet(name+" "+command, pflag.ContinueOnError)
+}
+
+// required reports an error for each of the named flags that wasn't set.
+func required(command string, flags *pflag.FlagSet, names ...string) error {
+	var missing []string
+	for _, n := range names {
+		if !flags.Changed(n) {
+			missing = append(missing, "--"+n)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s: missing %s", command, strings.Join(missing, ", "))
+	}
+	return nil
+}
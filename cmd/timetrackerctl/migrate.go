@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	postgres "github.com/KarmaBeLike/time-tracker-api/internal/database"
+)
+
+func migrateUp(ctx context.Context, c *cli, args []string) error {
+	if err := newFlags("migrate up").Parse(args); err != nil {
+		return err
+	}
+	return postgres.RunMigrations(c.db)
+}
+
+func migrateDown(ctx context.Context, c *cli, args []string) error {
+	flags := newFlags("migrate down")
+	steps := flags.Int("steps", 1, "number of migrations to roll back")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *steps < 1 {
+		return fmt.Errorf("--steps must be at least 1")
+	}
+
+	reverted, err := postgres.RollbackMigrations(ctx, c.db, *steps)
+	if err != nil {
+		return err
+	}
+	if len(reverted) == 0 {
+		fmt.Fprintln(c.out, "Nothing to roll back")
+	}
+	return nil
+}
+
+func migrateStatus(ctx context.Context, c *cli, args []string) error {
+	if err := newFlags("migrate status").Parse(args); err != nil {
+		return err
+	}
+
+	migrations, err := postgres.MigrationStatus(ctx, c.db)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tAPPLIED AT")
+	for _, m := range migrations {
+		applied := "pending"
+		if m.AppliedAt != nil {
+			applied = m.AppliedAt.Local().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\n", m.Version, applied)
+	}
+	return w.Flush()
+}
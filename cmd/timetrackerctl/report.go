@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+)
+
+const dateLayout = "2006-01-02"
+
+func exportWorklogs(ctx context.Context, c *cli, args []string) error {
+	flags := newFlags("report worklogs")
+	userId := flags.Int("user", 0, "user ID")
+	from := flags.String("from", "", "first day, YYYY-MM-DD; open if empty")
+	to := flags.String("to", "", "end of the period, YYYY-MM-DD; open if empty")
+	tags := flags.StringSlice("tag", nil, "count only worklogs with one of these tags")
+	format := flags.String("format", "table", "table, csv or json")
+	out := flags.String("out", "", "file to write to instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := required("report worklogs", flags, "user"); err != nil {
+		return err
+	}
+
+	startDate, endDate, err := reportPeriod(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	var write func(io.Writer, []models.Task) error
+	switch *format {
+	case "table":
+		write = writeTable
+	case "csv":
+		write = writeCSV
+	case "json":
+		write = writeJSON
+	default:
+		return fmt.Errorf("report worklogs: unknown format %q", *format)
+	}
+
+	tasks, err := c.taskService().GetWorklogs(ctx, *userId, startDate, endDate, *tags)
+	if err != nil {
+		return err
+	}
+
+	w := c.out
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := write(w, tasks); err != nil {
+		return err
+	}
+	if *out != "" {
+		fmt.Fprintf(c.out, "Wrote %d tasks to %s\n", len(tasks), *out)
+	}
+	return nil
+}
+
+// reportPeriod checks the dates of the period. Like the worklogs endpoint of
+// the API, it counts worklogs that end before the start of the to day.
+func reportPeriod(from, to string) (string, string, error) {
+	for flag, date := range map[string]string{"--from": from, "--to": to} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(dateLayout, date); err != nil {
+			return "", "", fmt.Errorf("invalid %s %q, expected YYYY-MM-DD", flag, date)
+		}
+	}
+	return from, to, nil
+}
+
+func writeTable(w io.Writer, tasks []models.Task) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TASK\tNAME\tTIME")
+	for _, t := range tasks {
+		fmt.Fprintf(tw, "%d\t%s\t%dh %02dm\n", t.ID, t.Name, t.TotalHours, t.TotalMinutes)
+	}
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, tasks []models.Task) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"task_id", "name", "description", "hours", "minutes"})
+	for _, t := range tasks {
+		cw.Write([]string{strconv.Itoa(t.ID), t.Name, t.Description, strconv.Itoa(t.TotalHours), strconv.Itoa(t.TotalMinutes)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeJSON(w io.Writer, tasks []models.Task) error {
+	if tasks == nil {
+		tasks = []models.Task{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(tasks)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/clock"
+	"github.com/KarmaBeLike/time-tracker-api/internal/external"
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+	"github.com/KarmaBeLike/time-tracker-api/internal/service"
+)
+
+func timerFlags(command string, args []string) (userId, taskId int, note string, err error) {
+	flags := newFlags(command)
+	flags.IntVar(&userId, "user", 0, "user ID")
+	flags.IntVar(&taskId, "task", 0, "task ID")
+	flags.StringVar(&note, "note", "", "what the work is about")
+	if err := flags.Parse(args); err != nil {
+		return 0, 0, "", err
+	}
+	return userId, taskId, note, required(command, flags, "user", "task")
+}
+
+func startTimer(ctx context.Context, c *cli, args []string) error {
+	userId, taskId, note, err := timerFlags("timers start", args)
+	if err != nil {
+		return err
+	}
+	at, err := c.taskService().StartTask(ctx, userId, taskId, note, nil)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "Started task %d for user %d at %s\n", taskId, userId, at.Format(time.RFC3339))
+	return nil
+}
+
+func stopTimer(ctx context.Context, c *cli, args []string) error {
+	userId, taskId, note, err := timerFlags("timers stop", args)
+	if err != nil {
+		return err
+	}
+	at, err := c.taskService().StopTask(ctx, userId, taskId, note)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "Stopped task %d for user %d at %s\n", taskId, userId, at.Format(time.RFC3339))
+	return nil
+}
+
+// sweepTimers runs one pass of the auto-stop sweeper of the API server, with
+// the same default policy and notifier.
+func sweepTimers(ctx context.Context, c *cli, args []string) error {
+	if err := newFlags("timers sweep").Parse(args); err != nil {
+		return err
+	}
+
+	defaultPolicy := models.AutoStopPolicy{
+		MaxDuration: models.Duration(c.cfg.AutoStopMaxDuration),
+		StopAt:      c.cfg.AutoStopAt,
+		Cap:         models.Duration(c.cfg.AutoStopCap),
+	}
+	sweeper := service.NewTimerSweeper(c.taskRepo(), external.NewNotifier(c.cfg.AutoStopWebhookURL), defaultPolicy, c.cfg.AutoStopInterval, clock.System)
+
+	stopped, err := sweeper.Sweep(ctx, clock.System.Now())
+	if err != nil {
+		return err
+	}
+	if len(stopped) == 0 {
+		fmt.Fprintln(c.out, "No stale timers")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "WORKLOG\tUSER\tTASK\tSTARTED\tSTOPPED AT")
+	for _, wl := range stopped {
+		fmt.Fprintf(w, "%d\t%d\t%d\t%s\t%s\n", wl.ID, wl.UserID, wl.TaskID,
+			wl.StartTime.Local().Format(time.RFC3339), wl.EndTime.Local().Format(time.RFC3339))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+)
+
+func createUser(ctx context.Context, c *cli, args []string) error {
+	flags := newFlags("users create")
+	passport := flags.String("passport", "", `passport number, e.g. "1234 567890"`)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := required("users create", flags, "passport"); err != nil {
+		return err
+	}
+
+	users, err := c.userService()
+	if err != nil {
+		return err
+	}
+	user, err := users.CreateUser(ctx, *passport)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "Created user %d: %s %s %s\n", user.ID, user.Surname, user.Name, user.Patronymic)
+	return nil
+}
+
+func listUsers(ctx context.Context, c *cli, args []string) error {
+	flags := newFlags("users list")
+	search := flags.String("search", "", "fuzzy search over the full name")
+	includeDeleted := flags.Bool("include-deleted", false, "include soft-deleted users")
+	page := flags.Int("page", 1, "page number")
+	limit := flags.Int("limit", 50, "page size")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *page < 1 || *limit < 1 {
+		return fmt.Errorf("--page and --limit must be at least 1")
+	}
+
+	users, err := c.userService()
+	if err != nil {
+		return err
+	}
+	list, err := users.GetUsers(ctx, models.UserListParams{
+		Filter: models.UserFilter{Search: *search, IncludeDeleted: *includeDeleted},
+		Page:   *page,
+		Limit:  *limit,
+	})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSURNAME\tNAME\tPATRONYMIC\tPASSPORT\tTEAM\tCREATED\tDELETED")
+	for _, u := range list.Users {
+		deleted := ""
+		if u.DeletedAt != nil {
+			deleted = u.DeletedAt.Local().Format("2006-01-02")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			u.ID, u.Surname, u.Name, u.Patronymic, u.PassportNumber, u.Team, u.CreatedAt.Local().Format("2006-01-02"), deleted)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "\n%d of %d users\n", len(list.Users), list.Total)
+	return nil
+}
+
+func deleteUser(ctx context.Context, c *cli, args []string) error {
+	flags := newFlags("users delete")
+	purge := flags.Bool("purge", false, "anonymise the user's personal data; can't be undone")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("users delete: expected a user ID")
+	}
+	userId, err := strconv.Atoi(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("users delete: invalid user ID %q", flags.Arg(0))
+	}
+
+	users, err := c.userService()
+	if err != nil {
+		return err
+	}
+
+	if *purge {
+		if err := users.PurgeUser(ctx, userId); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.out, "Purged user %d\n", userId)
+		return nil
+	}
+
+	if err := users.DeleteUser(ctx, userId); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "Deleted user %d\n", userId)
+	return nil
+}
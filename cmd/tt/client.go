@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+)
+
+// client calls the time tracker API.
+type client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func newClient(cfg *clientConfig) *client {
+	return &client{
+		baseURL: cfg.URL,
+		token:   cfg.Token,
+		http:    &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// problem is the problem+json body of an API error.
+type problem struct {
+	Status int    `json:"status"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+func (p *problem) Error() string {
+	return fmt.Sprintf("%s (%s)", p.Detail, p.Code)
+}
+
+// do sends a request with body encoded as JSON, if body isn't nil, and
+// decodes a successful JSON response into out, if out isn't nil. Error
+// responses are returned as *problem.
+func (c *client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		p := &problem{Status: resp.StatusCode}
+		if err := json.NewDecoder(resp.Body).Decode(p); err != nil || p.Detail == "" {
+			return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+		}
+		return p
+	}
+	if out == nil {
+		_, err := io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *client) ping(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/healthz", nil, nil, nil)
+}
+
+// timerResponse is the response of the start and stop endpoints.
+type timerResponse struct {
+	Status    string    `json:"status"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// timerRequest is the optional body of the start and stop endpoints.
+type timerRequest struct {
+	Note string `json:"note,omitempty"`
+}
+
+func (c *client) startTask(ctx context.Context, userId, taskId int, note string) (*timerResponse, error) {
+	var resp timerResponse
+	err := c.do(ctx, http.MethodPost, fmt.Sprintf("/tasks/%d/tasks/%d/start", userId, taskId), nil, timerRequest{Note: note}, &resp)
+	return &resp, err
+}
+
+func (c *client) stopTask(ctx context.Context, userId, taskId int, note string) (*timerResponse, error) {
+	var resp timerResponse
+	err := c.do(ctx, http.MethodPost, fmt.Sprintf("/tasks/%d/tasks/%d/stop", userId, taskId), nil, timerRequest{Note: note}, &resp)
+	return &resp, err
+}
+
+func (c *client) runningTimers(ctx context.Context, userId int) ([]models.Worklog, error) {
+	var resp struct {
+		Timers []models.Worklog `json:"timers"`
+	}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/tasks/%d/timers", userId), nil, nil, &resp)
+	return resp.Timers, err
+}
+
+// worklogs returns the time spent per task in [from, to). The API takes
+// whole days, so to must be a midnight.
+func (c *client) worklogs(ctx context.Context, userId int, from, to time.Time) ([]models.Task, error) {
+	var resp struct {
+		Worklogs []models.Task `json:"worklogs"`
+	}
+	query := url.Values{
+		"startDate": {from.Format(dateLayout)},
+		"endDate":   {to.Format(dateLayout)},
+	}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/tasks/%d/worklogs", userId), query, nil, &resp)
+	return resp.Worklogs, err
+}
+
+func (c *client) search(ctx context.Context, query url.Values) ([]models.SearchHit, error) {
+	var resp struct {
+		Hits []models.SearchHit `json:"hits"`
+	}
+	err := c.do(ctx, http.MethodGet, "/search", query, nil, &resp)
+	return resp.Hits, err
+}
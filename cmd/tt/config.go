@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// clientConfig is the config file of tt. TT_URL, TT_TOKEN and TT_USER
+// override its values.
+type clientConfig struct {
+	// URL is the base address of the API, e.g. http://localhost:8080.
+	URL string `json:"url"`
+	// Token is sent as a bearer token, for APIs behind an authenticating
+	// proxy.
+	Token string `json:"token,omitempty"`
+	// User is the ID of the user the commands act for by default.
+	User int `json:"user,omitempty"`
+}
+
+// configPath returns TT_CONFIG, or tt/config.json in the user config
+// directory, e.g. ~/.config/tt/config.json.
+func configPath() (string, error) {
+	if path := os.Getenv("TT_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name, "config.json"), nil
+}
+
+// loadConfig reads the config file, which may not exist yet, and applies the
+// environment on top of it.
+func loadConfig() (*clientConfig, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg clientConfig
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	if v := os.Getenv("TT_URL"); v != "" {
+		cfg.URL = v
+	}
+	if v := os.Getenv("TT_TOKEN"); v != "" {
+		cfg.Token = v
+	}
+	if v := os.Getenv("TT_USER"); v != "" {
+		user, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TT_USER %q", v)
+		}
+		cfg.User = user
+	}
+	return &cfg, nil
+}
+
+// save writes the config file. It is only readable by its owner, since it
+// holds the token.
+func (cfg *clientConfig) save() (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+func login(ctx context.Context, c *cli, args []string) error {
+	flags := pflag.NewFlagSet(name+" login", pflag.ContinueOnError)
+	apiURL := flags.String("url", c.cfg.URL, "base address of the API")
+	token := flags.String("token", c.cfg.Token, "bearer token, if the API requires one")
+	userId := flags.Int("user", c.cfg.User, "default user ID")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	u, err := url.Parse(*apiURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid --url %q, expected e.g. http://localhost:8080", *apiURL)
+	}
+
+	cfg := &clientConfig{URL: strings.TrimRight(*apiURL, "/"), Token: *token, User: *userId}
+	if err := newClient(cfg).ping(ctx); err != nil {
+		return fmt.Errorf("can't reach the API: %w", err)
+	}
+
+	path, err := cfg.save()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "Saved to %s\n", path)
+	return nil
+}
@@ -0,0 +1,117 @@
+// Command tt is a terminal client of the time tracker API.
+//
+// Usage:
+//
+//	tt login --url http://localhost:8080 --user 1
+//	tt start [--note text] <task>
+//	tt status
+//	tt stop [--note text] [task]
+//	tt report --week
+//	tt search [--all] [--from day] [--to day] <words>
+//
+// The API address, credentials and default user are kept in a config file,
+// see configPath.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+
+	"github.com/spf13/pflag"
+)
+
+const name = "tt"
+
+// command runs a subcommand with the arguments that follow it.
+type command struct {
+	usage string
+	run   func(ctx context.Context, c *cli, args []string) error
+}
+
+var commands = map[string]command{
+	"login":  {"save the API address, token and default user (--url, --token, --user)", login},
+	"start":  {"start a timer for a task: tt start [--note text] <task>", start},
+	"stop":   {"stop the running timer, or the one of the given task: tt stop [--note text] [task]", stop},
+	"status": {"show the running timers and how long they have been running", status},
+	"report": {"show the time spent per task (--week, --from, --to; today by default)", report},
+	"search": {"find tasks and your worklog notes: tt search [--all] [--from day] [--to day] <words>", search},
+}
+
+// cli holds the config and the API client of the commands. The client is
+// only set up after login.
+type cli struct {
+	cfg    *clientConfig
+	client *client
+	out    io.Writer
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if !errors.Is(err, pflag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, name+":", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	if len(args) == 0 {
+		usage(os.Stderr)
+		return pflag.ErrHelp
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		usage(os.Stderr)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return cmd.run(ctx, &cli{cfg: cfg, client: newClient(cfg), out: os.Stdout}, args[1:])
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags] [args]\n\nCommands:\n", name)
+
+	names := make([]string, 0, len(commands))
+	for n := range commands {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		fmt.Fprintf(w, "  %-8s %s\n", n, commands[n].usage)
+	}
+	fmt.Fprintf(w, "\nEvery command but login takes --user to act for another user than the default one.\n")
+}
+
+// newFlags returns the flag set of a command with the --user flag that
+// overrides the default user of the config.
+func newFlags(c *cli, command string) (*pflag.FlagSet, *int) {
+	flags := pflag.NewFlagSet(name+" "+command, pflag.ContinueOnError)
+	userId := flags.Int("user", c.cfg.User, "user ID")
+	return flags, userId
+}
+
+// user checks that a user was given and that the client is set up.
+func (c *cli) user(userId int) error {
+	if c.cfg.URL == "" {
+		return fmt.Errorf("no API address, run %s login first", name)
+	}
+	if userId == 0 {
+		return fmt.Errorf("no user, pass --user or run %s login --user <id>", name)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+func report(ctx context.Context, c *cli, args []string) error {
+	flags, userId := newFlags(c, "report")
+	week := flags.Bool("week", false, "the current week, from Monday")
+	from := flags.String("from", "", "first day, YYYY-MM-DD")
+	to := flags.String("to", "", "last day, YYYY-MM-DD; today if empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := c.user(*userId); err != nil {
+		return err
+	}
+
+	start, end, err := reportPeriod(time.Now(), *week, *from, *to)
+	if err != nil {
+		return err
+	}
+
+	tasks, err := c.client.worklogs(ctx, *userId, start, end)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "%s - %s\n\n", start.Format(dateLayout), end.AddDate(0, 0, -1).Format(dateLayout))
+	if len(tasks) == 0 {
+		fmt.Fprintln(c.out, "Nothing tracked")
+		return nil
+	}
+
+	var total time.Duration
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TASK\tNAME\tTIME")
+	for _, t := range tasks {
+		spent := time.Duration(t.TotalHours)*time.Hour + time.Duration(t.TotalMinutes)*time.Minute
+		total += spent
+		fmt.Fprintf(w, "%d\t%s\t%s\n", t.ID, t.Name, formatDuration(spent))
+	}
+	fmt.Fprintf(w, "\tTotal\t%s\n", formatDuration(total))
+	return w.Flush()
+}
+
+// reportPeriod returns the days of the report as [start, end) midnights:
+// today, the week of now, or from..to with both days included.
+func reportPeriod(now time.Time, week bool, from, to string) (time.Time, time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	if week {
+		if from != "" || to != "" {
+			return time.Time{}, time.Time{}, fmt.Errorf("report: --week can't be combined with --from or --to")
+		}
+		// Неделя начинается с понедельника
+		monday := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+		return monday, monday.AddDate(0, 0, 7), nil
+	}
+
+	start, end := today, today
+	if from != "" {
+		d, err := time.ParseInLocation(dateLayout, from, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --from %q, expected YYYY-MM-DD", from)
+		}
+		start = d
+	}
+	if to != "" {
+		d, err := time.ParseInLocation(dateLayout, to, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --to %q, expected YYYY-MM-DD", to)
+		}
+		end = d
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("report: --to is before --from")
+	}
+	return start, end.AddDate(0, 0, 1), nil
+}
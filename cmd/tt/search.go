@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func search(ctx context.Context, c *cli, args []string) error {
+	flags, userId := newFlags(c, "search")
+	all := flags.Bool("all", false, "search the worklogs of every user, with the admin token as --token")
+	from := flags.String("from", "", "only worklogs started on this day or later, YYYY-MM-DD")
+	to := flags.String("to", "", "only worklogs started on this day or earlier, YYYY-MM-DD")
+	limit := flags.Int("limit", 20, "maximum number of results, up to 100")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("search: nothing to search for")
+	}
+	if err := c.user(*userId); err != nil {
+		return err
+	}
+
+	query := url.Values{
+		"q":     {strings.Join(flags.Args(), " ")},
+		"limit": {strconv.Itoa(*limit)},
+	}
+	if *all {
+		query.Set("all", "true")
+	} else {
+		query.Set("userId", strconv.Itoa(*userId))
+	}
+	if *from != "" {
+		if _, err := time.Parse(dateLayout, *from); err != nil {
+			return fmt.Errorf("invalid --from %q, expected YYYY-MM-DD", *from)
+		}
+		query.Set("startDate", *from)
+	}
+	if *to != "" {
+		d, err := time.Parse(dateLayout, *to)
+		if err != nil {
+			return fmt.Errorf("invalid --to %q, expected YYYY-MM-DD", *to)
+		}
+		// API не включает последний день
+		query.Set("endDate", d.AddDate(0, 0, 1).Format(dateLayout))
+	}
+
+	hits, err := c.client.search(ctx, query)
+	if err != nil {
+		return err
+	}
+	if len(hits) == 0 {
+		fmt.Fprintln(c.out, "Nothing found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "WHEN\tTASK\tNAME\tMATCH")
+	for _, hit := range hits {
+		when := "task"
+		if hit.StartTime != nil {
+			when = hit.StartTime.Local().Format(dateLayout)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", when, hit.TaskID, hit.TaskName, plainSnippet(hit.Snippet))
+	}
+	return w.Flush()
+}
+
+// plainSnippet turns an HTML snippet into a single line of text.
+func plainSnippet(snippet string) string {
+	snippet = strings.NewReplacer("<b>", "", "</b>", "").Replace(snippet)
+	return strings.Join(strings.Fields(html.UnescapeString(snippet)), " ")
+}
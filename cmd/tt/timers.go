@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+)
+
+// taskArg parses the task ID argument of start and stop.
+func taskArg(command string, args []string) (int, error) {
+	taskId, err := strconv.Atoi(args[0])
+	if err != nil || taskId < 1 {
+		return 0, fmt.Errorf("%s: invalid task ID %q", command, args[0])
+	}
+	return taskId, nil
+}
+
+func start(ctx context.Context, c *cli, args []string) error {
+	flags, userId := newFlags(c, "start")
+	note := flags.String("note", "", "what you are working on")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("start: expected a task ID")
+	}
+	taskId, err := taskArg("start", flags.Args())
+	if err != nil {
+		return err
+	}
+	if err := c.user(*userId); err != nil {
+		return err
+	}
+
+	resp, err := c.client.startTask(ctx, *userId, taskId, *note)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "Started task %d at %s\n", taskId, resp.Timestamp.Local().Format("15:04"))
+	return nil
+}
+
+// stop stops the timer of the given task. Without a task it stops the running
+// timer, if there is exactly one.
+func stop(ctx context.Context, c *cli, args []string) error {
+	flags, userId := newFlags(c, "stop")
+	note := flags.String("note", "", "what you did, added to the note given at the start")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return fmt.Errorf("stop: expected at most one task ID")
+	}
+	if err := c.user(*userId); err != nil {
+		return err
+	}
+
+	timers, err := c.client.runningTimers(ctx, *userId)
+	if err != nil {
+		return err
+	}
+
+	var timer *models.Worklog
+	if flags.NArg() == 1 {
+		taskId, err := taskArg("stop", flags.Args())
+		if err != nil {
+			return err
+		}
+		for i := range timers {
+			if timers[i].TaskID == taskId {
+				timer = &timers[i]
+				break
+			}
+		}
+		if timer == nil {
+			return fmt.Errorf("no running timer for task %d", taskId)
+		}
+	} else {
+		switch len(timers) {
+		case 0:
+			return fmt.Errorf("no running timer")
+		case 1:
+			timer = &timers[0]
+		default:
+			ids := make([]string, len(timers))
+			for i, t := range timers {
+				ids[i] = strconv.Itoa(t.TaskID)
+			}
+			return fmt.Errorf("%d timers are running, pass one of the tasks %s", len(timers), strings.Join(ids, ", "))
+		}
+	}
+
+	resp, err := c.client.stopTask(ctx, *userId, timer.TaskID, *note)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "Stopped %s after %s\n", taskLabel(*timer), formatDuration(resp.Timestamp.Sub(timer.StartTime)))
+	return nil
+}
+
+func status(ctx context.Context, c *cli, args []string) error {
+	flags, userId := newFlags(c, "status")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := c.user(*userId); err != nil {
+		return err
+	}
+
+	timers, err := c.client.runningTimers(ctx, *userId)
+	if err != nil {
+		return err
+	}
+	if len(timers) == 0 {
+		fmt.Fprintln(c.out, "No running timer")
+		return nil
+	}
+
+	now := time.Now()
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TASK\tNAME\tSTARTED\tELAPSED")
+	for _, t := range timers {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s", t.TaskID, t.TaskName, formatStart(t.StartTime, now), formatDuration(now.Sub(t.StartTime)))
+		// Простой, который ждёт решения пользователя
+		if t.IdleStatus == models.IdleProposed && t.LastActivityAt != nil {
+			fmt.Fprintf(w, "\tidle since %s", t.LastActivityAt.Local().Format("15:04"))
+		}
+		fmt.Fprintln(w)
+	}
+	return w.Flush()
+}
+
+func taskLabel(w models.Worklog) string {
+	if w.TaskName == "" {
+		return fmt.Sprintf("task %d", w.TaskID)
+	}
+	return fmt.Sprintf("task %d (%s)", w.TaskID, w.TaskName)
+}
+
+// formatStart shows the time of day for timers started today and the date
+// as well for older ones.
+func formatStart(t, now time.Time) string {
+	t = t.Local()
+	if y, m, d := t.Date(); y == now.Year() && m == now.Month() && d == now.Day() {
+		return t.Format("15:04")
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+// formatDuration formats a duration like the reports, e.g. "2h 05m".
+func formatDuration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	d = d.Truncate(time.Minute)
+	return fmt.Sprintf("%dh %02dm", int(d.Hours()), int(d.Minutes())%60)
+}
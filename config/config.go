@@ -1,8 +1,15 @@
 package config
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
 
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -12,23 +19,238 @@ type Config struct {
 	DBPort           int    `mapstructure:"DB_PORT"`
 	DBName           string `mapstructure:"DB_NAME"`
 	DBUser           string `mapstructure:"DB_USER"`
-	DBPassword       string `mapstructure:"DB_PASSWORD"`
+	DBPassword       string `mapstructure:"DB_PASSWORD" secret:"true"`
 	PeopleAPIBaseURL string `mapstructure:"PEOPLE_API_BASE_URL"`
+
+	// Подключение к БД. DB_URL заменяет все параметры подключения целиком
+	DBHost        string `mapstructure:"DB_HOST"`
+	DBURL         string `mapstructure:"DB_URL" secret:"true"`
+	DBSSLMode     string `mapstructure:"DB_SSL_MODE"`
+	DBSSLRootCert string `mapstructure:"DB_SSL_ROOT_CERT"`
+	DBSSLCert     string `mapstructure:"DB_SSL_CERT"`
+	DBSSLKey      string `mapstructure:"DB_SSL_KEY"`
+
+	// Пул соединений и ожидание БД при старте
+	DBMaxOpenConns    int           `mapstructure:"DB_MAX_OPEN_CONNS"`
+	DBMaxIdleConns    int           `mapstructure:"DB_MAX_IDLE_CONNS"`
+	DBConnMaxLifetime time.Duration `mapstructure:"DB_CONN_MAX_LIFETIME"`
+	DBConnMaxIdleTime time.Duration `mapstructure:"DB_CONN_MAX_IDLE_TIME"`
+	DBConnectTimeout  time.Duration `mapstructure:"DB_CONNECT_TIMEOUT"`
+
+	// Ограничение времени выполнения запросов к БД; отчёты получают своё
+	DBQueryTimeout  time.Duration `mapstructure:"DB_QUERY_TIMEOUT"`
+	DBReportTimeout time.Duration `mapstructure:"DB_REPORT_TIMEOUT"`
+
+	PeopleProvider      string `mapstructure:"PEOPLE_PROVIDER"`
+	PeopleProviderChain string `mapstructure:"PEOPLE_PROVIDER_CHAIN"`
+	PeopleFilePath      string `mapstructure:"PEOPLE_FILE_PATH"`
+
+	ShutdownTimeout time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+
+	// Как долго кешируется проверка доступности People API в /readyz
+	PeopleAPIHealthTTL time.Duration `mapstructure:"PEOPLE_API_HEALTH_TTL"`
+
+	// Автоостановка забытых таймеров; политика по умолчанию
+	AutoStopInterval    time.Duration `mapstructure:"AUTO_STOP_INTERVAL"`
+	AutoStopMaxDuration time.Duration `mapstructure:"AUTO_STOP_MAX_DURATION"`
+	AutoStopAt          string        `mapstructure:"AUTO_STOP_AT"`
+	AutoStopCap         time.Duration `mapstructure:"AUTO_STOP_CAP"`
+	AutoStopWebhookURL  string        `mapstructure:"AUTO_STOP_WEBHOOK_URL" secret:"true"`
+
+	// Определение простоя по heartbeat-запросам клиентов
+	IdleThreshold     time.Duration `mapstructure:"IDLE_THRESHOLD"`
+	IdleMode          string        `mapstructure:"IDLE_MODE"`
+	IdleCheckInterval time.Duration `mapstructure:"IDLE_CHECK_INTERVAL"`
+
+	// Журналирование: уровень, формат (json или console) и файл с ротацией по размеру
+	LogLevel      string `mapstructure:"LOG_LEVEL"`
+	LogFormat     string `mapstructure:"LOG_FORMAT"`
+	LogStacktrace bool   `mapstructure:"LOG_STACKTRACE"`
+	LogFile       string `mapstructure:"LOG_FILE"`
+	LogMaxSizeMB  int    `mapstructure:"LOG_MAX_SIZE_MB"`
+	LogMaxBackups int    `mapstructure:"LOG_MAX_BACKUPS"`
+
+	// Токен для маршрутов /admin и поиска по всем пользователям; без него они выключены
+	AdminToken string `mapstructure:"ADMIN_TOKEN" secret:"true"`
+
+	// Трассировка OpenTelemetry: none, otlp или stdout
+	TracingExporter    string  `mapstructure:"TRACING_EXPORTER"`
+	TracingServiceName string  `mapstructure:"TRACING_SERVICE_NAME"`
+	TracingSampleRatio float64 `mapstructure:"TRACING_SAMPLE_RATIO"`
+
+	// Файлы, из которых прочитаны секреты, по ключу настройки
+	secretFiles map[string]string
 }
 
-func Load() (*Config, error) {
-	config := &Config{}
+// defaults are the lowest configuration layer.
+var defaults = map[string]any{
+	// Сервер слушает все интерфейсы, а БД по умолчанию локальная
+	"HOST":                   "",
+	"PORT":                   8080,
+	"DB_HOST":                "localhost",
+	"DB_PORT":                5432,
+	"DB_SSL_MODE":            "disable",
+	"DB_MAX_OPEN_CONNS":      25,
+	"DB_MAX_IDLE_CONNS":      10,
+	"DB_CONN_MAX_LIFETIME":   30 * time.Minute,
+	"DB_CONN_MAX_IDLE_TIME":  5 * time.Minute,
+	"DB_CONNECT_TIMEOUT":     time.Minute,
+	"SHUTDOWN_TIMEOUT":       15 * time.Second,
+	"DB_QUERY_TIMEOUT":       5 * time.Second,
+	"DB_REPORT_TIMEOUT":      30 * time.Second,
+	"PEOPLE_PROVIDER":        "http",
+	"PEOPLE_API_HEALTH_TTL":  30 * time.Second,
+	"AUTO_STOP_INTERVAL":     5 * time.Minute,
+	"AUTO_STOP_MAX_DURATION": 12 * time.Hour,
+	"AUTO_STOP_AT":           "cap",
+	"AUTO_STOP_CAP":          8 * time.Hour,
+	"IDLE_THRESHOLD":         15 * time.Minute,
+	"IDLE_MODE":              "propose",
+	"IDLE_CHECK_INTERVAL":    time.Minute,
+	"LOG_LEVEL":              "debug",
+	"LOG_FORMAT":             "json",
+	"LOG_STACKTRACE":         true,
+	"LOG_MAX_SIZE_MB":        100,
+	"LOG_MAX_BACKUPS":        5,
+	"TRACING_EXPORTER":       "none",
+	"TRACING_SERVICE_NAME":   "time-tracker-api",
+	"TRACING_SAMPLE_RATIO":   1.0,
+}
 
-	viper.SetConfigFile(".env")
+// defaultConfigFile is read when no file is given explicitly and it exists.
+const defaultConfigFile = ".env"
 
-	err := viper.ReadInConfig()
+// Load builds the configuration from, in increasing order of precedence:
+// defaults, an optional config file, environment variables and command-line
+// flags. Every setting has an environment variable named after its key, e.g.
+// DB_PORT, and a flag, e.g. --db-port. Secrets can also be read from a file
+// named by the _FILE variant of their key, e.g. DB_PASSWORD_FILE. The file is given by --config or
+// CONFIG_FILE and may be YAML, JSON, TOML or .env; without either, .env in the
+// working directory is used if present. The result is validated.
+func Load(args []string) (*Config, error) {
+	config, _, err := load("time-tracker-api", args, true)
 	if err != nil {
-		log.Fatal("Can't find the .env file: ", err)
+		return nil, err
 	}
+	return config, config.Validate()
+}
 
-	err = viper.Unmarshal(&config)
+// LoadCommand is Load for command-line tools: config flags are only parsed
+// up to the first positional argument, which starts the command. The command
+// and its arguments are returned. The people provider isn't validated, as
+// most commands don't use it; see ValidatePeople.
+func LoadCommand(name string, args []string) (*Config, []string, error) {
+	config, rest, err := load(name, args, false)
 	if err != nil {
-		log.Fatal("Environment can't be loaded: ", err)
+		return nil, nil, err
+	}
+	if err := config.validate(false); err != nil {
+		return nil, nil, err
+	}
+	return config, rest, nil
+}
+
+func load(name string, args []string, interspersed bool) (*Config, []string, error) {
+	v := viper.New()
+
+	flags := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	flags.SetInterspersed(interspersed)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "config file (YAML, JSON, TOML or .env)")
+
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("mapstructure")
+		if key == "" {
+			continue
+		}
+		if value, ok := defaults[key]; ok {
+			v.SetDefault(key, value)
+		}
+		if err := v.BindEnv(key); err != nil {
+			return nil, nil, err
+		}
+		if err := v.BindPFlag(key, addFlag(flags, field)); err != nil {
+			return nil, nil, err
+		}
+		if isSecret(field) {
+			fileKey := key + secretFileSuffix
+			if err := v.BindEnv(fileKey); err != nil {
+				return nil, nil, err
+			}
+			name := strings.ReplaceAll(strings.ToLower(fileKey), "_", "-")
+			flags.String(name, "", "file to read "+key+" from")
+			if err := v.BindPFlag(fileKey, flags.Lookup(name)); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	path, explicit := *configFile, *configFile != ""
+	if !explicit {
+		path = defaultConfigFile
+	}
+	if err := readConfigFile(v, path, explicit); err != nil {
+		return nil, nil, err
+	}
+
+	config := &Config{}
+	if err := v.Unmarshal(config); err != nil {
+		return nil, nil, fmt.Errorf("decode config: %w", err)
+	}
+	if err := config.loadSecretFiles(v); err != nil {
+		return nil, nil, err
+	}
+	return config, flags.Args(), nil
+}
+
+func readConfigFile(v *viper.Viper, path string, explicit bool) error {
+	if _, err := os.Stat(path); err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("config file: %w", err)
+	}
+
+	v.SetConfigFile(path)
+	// У файлов вида .env нет расширения, по которому viper определяет формат
+	if ext := filepath.Ext(path); ext == "" || ext == ".env" || strings.HasPrefix(filepath.Base(path), ".env") {
+		v.SetConfigType("env")
+	}
+	if err := v.ReadInConfig(); err != nil {
+		return fmt.Errorf("read config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// addFlag defines a flag for a Config field, typed like the field and named
+// after its key, e.g. --db-port for DB_PORT.
+func addFlag(flags *pflag.FlagSet, field reflect.StructField) *pflag.Flag {
+	key := field.Tag.Get("mapstructure")
+	name := strings.ReplaceAll(strings.ToLower(key), "_", "-")
+	usage := "overrides " + key
+	def := defaults[key]
+
+	switch field.Type {
+	case reflect.TypeOf(time.Duration(0)):
+		value, _ := def.(time.Duration)
+		flags.Duration(name, value, usage)
+	case reflect.TypeOf(0):
+		value, _ := def.(int)
+		flags.Int(name, value, usage)
+	case reflect.TypeOf(false):
+		value, _ := def.(bool)
+		flags.Bool(name, value, usage)
+	case reflect.TypeOf(0.0):
+		value, _ := def.(float64)
+		flags.Float64(name, value, usage)
+	default:
+		value, _ := def.(string)
+		flags.String(name, value, usage)
 	}
-	return config, nil
+	return flags.Lookup(name)
 }
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// secretFileSuffix turns the key of a secret into the key of the file it can
+// be read from, e.g. DB_PASSWORD into DB_PASSWORD_FILE.
+const secretFileSuffix = "_FILE"
+
+const redacted = "[REDACTED]"
+
+// isSecret reports whether a Config field holds a credential. Secrets can be
+// read from files and are never logged.
+func isSecret(field reflect.StructField) bool {
+	return field.Tag.Get("secret") == "true"
+}
+
+// loadSecretFiles reads every secret whose _FILE variant is set from that
+// file, in the style of Docker and Kubernetes secrets. A file takes precedence
+// over the plain value. The paths are kept for ReloadSecrets.
+func (c *Config) loadSecretFiles(v *viper.Viper) error {
+	c.secretFiles = make(map[string]string)
+
+	t := reflect.TypeOf(*c)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !isSecret(field) {
+			continue
+		}
+		key := field.Tag.Get("mapstructure")
+		if path := v.GetString(key + secretFileSuffix); path != "" {
+			c.secretFiles[key] = path
+		}
+	}
+
+	return c.readSecretFiles()
+}
+
+func (c *Config) readSecretFiles() error {
+	value := reflect.ValueOf(c).Elem()
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := t.Field(i).Tag.Get("mapstructure")
+		path, ok := c.secretFiles[key]
+		if !ok {
+			continue
+		}
+		secret, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read %s%s: %w", key, secretFileSuffix, err)
+		}
+		// Файлы секретов обычно заканчиваются переводом строки
+		value.Field(i).SetString(strings.TrimRight(string(secret), "\r\n"))
+	}
+	return nil
+}
+
+// ReloadSecrets reads the secret files again and returns a copy of c with
+// their current contents. c itself is left untouched.
+func (c *Config) ReloadSecrets() (*Config, error) {
+	reloaded := *c
+	if err := reloaded.readSecretFiles(); err != nil {
+		return nil, err
+	}
+	return &reloaded, nil
+}
+
+// Redacted returns the settings keyed by their name, safe to be logged:
+// secrets are masked and so are passwords in URLs.
+func (c *Config) Redacted() map[string]any {
+	settings := make(map[string]any)
+
+	value := reflect.ValueOf(*c)
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("mapstructure")
+		if key == "" {
+			continue
+		}
+
+		switch v := value.Field(i).Interface().(type) {
+		case string:
+			if isSecret(field) && v != "" {
+				settings[key] = redacted
+			} else {
+				settings[key] = redactURL(v)
+			}
+		case fmt.Stringer:
+			settings[key] = v.String()
+		default:
+			settings[key] = v
+		}
+
+		if path, ok := c.secretFiles[key]; ok {
+			settings[key+secretFileSuffix] = path
+		}
+	}
+	return settings
+}
+
+func redactURL(s string) string {
+	if !strings.Contains(s, "://") {
+		return s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+	return u.Redacted()
+}
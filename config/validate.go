@@ -0,0 +1,155 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/KarmaBeLike/time-tracker-api/pkg/logger"
+)
+
+// ValidationError lists every invalid setting, named by its key.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks that required settings are present and that all settings
+// are in range. All problems are reported at once.
+func (c *Config) Validate() error {
+	return c.validate(true)
+}
+
+// ValidatePeople checks the settings of the people provider alone, for tools
+// that only need it for some commands.
+func (c *Config) ValidatePeople() error {
+	var v validator
+	c.checkPeople(&v)
+	return v.err()
+}
+
+// validate checks all settings, those of the people provider only with people.
+func (c *Config) validate(people bool) error {
+	var v validator
+
+	v.port("PORT", c.Port)
+	if c.DBURL == "" {
+		v.port("DB_PORT", c.DBPort)
+		v.check(c.DBName != "", "DB_NAME is required unless DB_URL is set")
+		v.check(c.DBUser != "", "DB_USER is required unless DB_URL is set")
+		v.oneOf("DB_SSL_MODE", c.DBSSLMode, "disable", "require", "verify-ca", "verify-full")
+		v.check((c.DBSSLCert == "") == (c.DBSSLKey == ""), "DB_SSL_CERT and DB_SSL_KEY must be set together")
+	}
+	v.check(c.DBMaxOpenConns >= 0, "DB_MAX_OPEN_CONNS can't be negative")
+	v.check(c.DBMaxIdleConns >= 0, "DB_MAX_IDLE_CONNS can't be negative")
+	v.nonNegative("DB_CONN_MAX_LIFETIME", c.DBConnMaxLifetime)
+	v.nonNegative("DB_CONN_MAX_IDLE_TIME", c.DBConnMaxIdleTime)
+	v.nonNegative("DB_CONNECT_TIMEOUT", c.DBConnectTimeout)
+
+	if people {
+		c.checkPeople(&v)
+	}
+
+	v.check(c.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT must be positive")
+	v.nonNegative("DB_QUERY_TIMEOUT", c.DBQueryTimeout)
+	v.nonNegative("DB_REPORT_TIMEOUT", c.DBReportTimeout)
+	v.nonNegative("PEOPLE_API_HEALTH_TTL", c.PeopleAPIHealthTTL)
+
+	v.nonNegative("AUTO_STOP_INTERVAL", c.AutoStopInterval)
+	v.nonNegative("AUTO_STOP_MAX_DURATION", c.AutoStopMaxDuration)
+	v.nonNegative("AUTO_STOP_CAP", c.AutoStopCap)
+	v.oneOf("AUTO_STOP_AT", c.AutoStopAt, "cap", "last_activity")
+	if c.AutoStopWebhookURL != "" {
+		v.url("AUTO_STOP_WEBHOOK_URL", c.AutoStopWebhookURL)
+	}
+
+	v.nonNegative("IDLE_THRESHOLD", c.IdleThreshold)
+	v.nonNegative("IDLE_CHECK_INTERVAL", c.IdleCheckInterval)
+	v.oneOf("IDLE_MODE", c.IdleMode, "propose", "apply")
+
+	if _, err := logger.ParseLevel(c.LogLevel); err != nil {
+		v.add("LOG_LEVEL must be debug, info, error, fatal or off, got %q", c.LogLevel)
+	}
+	v.oneOf("LOG_FORMAT", strings.ToLower(c.LogFormat), logger.FormatJSON, logger.FormatConsole)
+	v.check(c.LogMaxSizeMB >= 0, "LOG_MAX_SIZE_MB can't be negative")
+	v.check(c.LogMaxBackups >= 0, "LOG_MAX_BACKUPS can't be negative")
+
+	v.oneOf("TRACING_EXPORTER", strings.ToLower(c.TracingExporter), "none", "otlp", "stdout")
+	v.check(c.TracingSampleRatio >= 0 && c.TracingSampleRatio <= 1, "TRACING_SAMPLE_RATIO must be between 0 and 1")
+
+	return v.err()
+}
+
+// checkPeople checks the settings of the selected people provider.
+func (c *Config) checkPeople(v *validator) {
+	providers := []string{c.PeopleProvider}
+	switch strings.ToLower(c.PeopleProvider) {
+	case "", "http", "file":
+	case "chain":
+		providers = strings.Split(c.PeopleProviderChain, ",")
+		v.check(strings.TrimSpace(c.PeopleProviderChain) != "", "PEOPLE_PROVIDER_CHAIN is required when PEOPLE_PROVIDER is chain")
+	default:
+		v.add("PEOPLE_PROVIDER must be http, file or chain, got %q", c.PeopleProvider)
+	}
+	for _, provider := range providers {
+		switch strings.ToLower(strings.TrimSpace(provider)) {
+		case "", "http":
+			v.url("PEOPLE_API_BASE_URL", c.PeopleAPIBaseURL)
+		case "file":
+			v.check(c.PeopleFilePath != "", "PEOPLE_FILE_PATH is required by the file people provider")
+		}
+	}
+}
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) add(format string, args ...any) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+func (v *validator) check(ok bool, problem string) {
+	if !ok {
+		v.problems = append(v.problems, problem)
+	}
+}
+
+func (v *validator) port(key string, port int) {
+	v.check(port > 0 && port <= 65535, key+" must be between 1 and 65535")
+}
+
+func (v *validator) nonNegative(key string, d time.Duration) {
+	v.check(d >= 0, key+" can't be negative")
+}
+
+func (v *validator) oneOf(key, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.add("%s must be one of %s, got %q", key, strings.Join(allowed, ", "), value)
+}
+
+func (v *validator) url(key, value string) {
+	if value == "" {
+		v.add("%s is required", key)
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		v.add("%s must be an absolute URL, got %q", key, value)
+	}
+}
+
+func (v *validator) err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: v.problems}
+}
@@ -0,0 +1,272 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/KarmaBeLike/time-tracker-api/config"
+	"github.com/KarmaBeLike/time-tracker-api/internal/clock"
+	postgres "github.com/KarmaBeLike/time-tracker-api/internal/database"
+	"github.com/KarmaBeLike/time-tracker-api/internal/external"
+	"github.com/KarmaBeLike/time-tracker-api/internal/metrics"
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+	repositories "github.com/KarmaBeLike/time-tracker-api/internal/repository"
+	"github.com/KarmaBeLike/time-tracker-api/internal/service"
+	"github.com/KarmaBeLike/time-tracker-api/internal/tracing"
+	"github.com/KarmaBeLike/time-tracker-api/pkg/logger"
+)
+
+// Worker is a background job that runs until its context is cancelled.
+type Worker func(ctx context.Context)
+
+// App owns the dependencies of the API server and their lifecycle.
+type App struct {
+	cfg    *config.Config
+	db     *sql.DB
+	router *Router
+	server *http.Server
+
+	workers     []Worker
+	workersWG   sync.WaitGroup
+	stopWorkers context.CancelFunc
+
+	shutdownTracing func(context.Context) error
+
+	// Получатели секретов, которые можно перечитать без перезапуска
+	dbConnector *postgres.Connector
+	notifier    *external.ReloadableNotifier
+}
+
+// New connects to the database, runs migrations and wires repositories,
+// services and handlers. Nothing is served until Run is called.
+func New(cfg *config.Config) (*App, error) {
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	connector := postgres.NewConnector(postgres.DSN(cfg))
+	db, err := postgres.Open(connector, cfg)
+	if err != nil {
+		shutdownTracing(context.Background())
+		return nil, err
+	}
+
+	// Выполнение миграций
+	if err := postgres.RunMigrations(db); err != nil {
+		db.Close()
+		shutdownTracing(context.Background())
+		return nil, err
+	}
+
+	app, err := newApp(cfg, db)
+	if err != nil {
+		db.Close()
+		shutdownTracing(context.Background())
+		return nil, err
+	}
+	app.shutdownTracing = shutdownTracing
+	app.dbConnector = connector
+	return app, nil
+}
+
+func newApp(cfg *config.Config, db *sql.DB) (*App, error) {
+	a := &App{cfg: cfg, db: db, notifier: external.NewReloadableNotifier(cfg.AutoStopWebhookURL)}
+
+	m := metrics.New()
+	m.RegisterDB(db, cfg.DBName)
+
+	timeouts := repositories.Timeouts{Query: cfg.DBQueryTimeout, Report: cfg.DBReportTimeout}
+
+	// Инициализация репозиториев
+	repos := Repositories{
+		Users:    repositories.NewUserRepository(db, timeouts),
+		Tasks:    repositories.NewTaskRepository(db, timeouts),
+		Tags:     repositories.NewTagRepository(db, timeouts),
+		Search:   repositories.NewSearchRepository(db, timeouts),
+		Policies: repositories.NewAutoStopPolicyRepository(db, timeouts),
+	}
+	taskRepo := repos.Tasks
+
+	personProvider, err := external.NewPersonInfoProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	personProvider = m.InstrumentProvider(personProvider)
+
+	m.RegisterGauge("running_timers", "Number of timers that are currently running.", func() (float64, error) {
+		count, err := taskRepo.CountOpenWorklogs(context.Background())
+		return float64(count), err
+	})
+	m.RegisterGauge("pending_idle_proposals", "Number of worklogs with idle time awaiting the user's decision.", func() (float64, error) {
+		count, err := taskRepo.CountPendingIdle(context.Background())
+		return float64(count), err
+	})
+
+	// Фоновая автоостановка забытых таймеров
+	if cfg.AutoStopInterval > 0 {
+		defaultPolicy := models.AutoStopPolicy{
+			MaxDuration: models.Duration(cfg.AutoStopMaxDuration),
+			StopAt:      cfg.AutoStopAt,
+			Cap:         models.Duration(cfg.AutoStopCap),
+		}
+		sweeper := service.NewTimerSweeper(taskRepo, a.notifier, defaultPolicy, cfg.AutoStopInterval, clock.System)
+		a.AddWorker(sweeper.Run)
+	}
+
+	// Обработка простоя по heartbeat-запросам
+	if cfg.IdleThreshold > 0 && cfg.IdleCheckInterval > 0 {
+		detector := service.NewIdleDetector(taskRepo, cfg.IdleThreshold, cfg.IdleMode == "apply", cfg.IdleCheckInterval, clock.System)
+		a.AddWorker(detector.Run)
+	}
+
+	checks := []service.HealthCheck{
+		{Name: "database", Check: db.PingContext},
+		{Name: "migrations", Check: func(ctx context.Context) error {
+			pending, err := postgres.PendingMigrations(ctx, db)
+			if err != nil {
+				return err
+			}
+			if len(pending) > 0 {
+				return fmt.Errorf("%d pending migrations, first is %s", len(pending), pending[0])
+			}
+			return nil
+		}},
+	}
+	if pinger, ok := personProvider.(external.Pinger); ok {
+		checks = append(checks, service.HealthCheck{Name: "peopleApi", Check: service.CachedCheck(pinger.Ping, cfg.PeopleAPIHealthTTL)})
+	}
+	a.router = NewRouter(cfg, Dependencies{
+		Repositories: repos,
+		People:       personProvider,
+		Clock:        clock.System,
+		Metrics:      m,
+		SchemaVersion: func(ctx context.Context) (string, error) {
+			return postgres.SchemaVersion(ctx, db)
+		},
+		Checks: checks,
+	})
+
+	a.server = &http.Server{
+		Addr:    net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Handler: a.router,
+	}
+
+	return a, nil
+}
+
+// ReloadSecrets reads the secret files of the configuration again and hands
+// changed credentials to their users. New database connections use the new
+// password; connections in the pool are kept until they expire.
+func (a *App) ReloadSecrets() error {
+	cfg, err := a.cfg.ReloadSecrets()
+	if err != nil {
+		return err
+	}
+
+	var changed []string
+	if a.dbConnector != nil {
+		if dsn := postgres.DSN(cfg); dsn != a.dbConnector.DSN() {
+			a.dbConnector.SetDSN(dsn)
+			changed = append(changed, "database")
+		}
+	}
+	if a.notifier.SetWebhookURL(cfg.AutoStopWebhookURL) {
+		changed = append(changed, "autoStopWebhook")
+	}
+	if a.router.admin.Set(cfg.AdminToken) {
+		changed = append(changed, "adminToken")
+	}
+
+	logger.PrintInfo("Secrets reloaded", map[string]any{"changed": changed})
+	return nil
+}
+
+// AddWorker registers a background worker. Workers are started by Run and
+// stopped during shutdown, after the HTTP server has drained.
+func (a *App) AddWorker(w Worker) {
+	a.workers = append(a.workers, w)
+}
+
+// Run serves HTTP until ctx is cancelled or the server fails, then shuts
+// everything down within cfg.ShutdownTimeout.
+func (a *App) Run(ctx context.Context) error {
+	var workersCtx context.Context
+	workersCtx, a.stopWorkers = context.WithCancel(context.Background())
+	for _, w := range a.workers {
+		a.workersWG.Add(1)
+		go func(w Worker) {
+			defer a.workersWG.Done()
+			w(workersCtx)
+		}(w)
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.PrintInfo("Server is running", map[string]any{"addr": a.server.Addr})
+		if err := a.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		logger.PrintInfo("Shutdown signal received", nil)
+	case err := <-serverErr:
+		runErr = fmt.Errorf("http server: %w", err)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.cfg.ShutdownTimeout)
+	defer cancel()
+
+	return errors.Join(runErr, a.Shutdown(shutdownCtx))
+}
+
+// Shutdown stops accepting connections, waits for in-flight requests, stops
+// background workers and closes the database, in that order. Whatever is
+// still running when ctx expires is abandoned.
+func (a *App) Shutdown(ctx context.Context) error {
+	var errs []error
+
+	if err := a.server.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("drain http server: %w", err))
+	}
+
+	if a.stopWorkers != nil {
+		a.stopWorkers()
+		done := make(chan struct{})
+		go func() {
+			a.workersWG.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			errs = append(errs, errors.New("background workers did not stop in time"))
+		}
+	}
+
+	if err := a.db.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("close database: %w", err))
+	}
+
+	// Отправка оставшихся спанов
+	if a.shutdownTracing != nil {
+		if err := a.shutdownTracing(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("flush traces: %w", err))
+		}
+	}
+
+	if len(errs) == 0 {
+		logger.PrintInfo("Server stopped gracefully", nil)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"context"
+
+	"github.com/KarmaBeLike/time-tracker-api/config"
+	_ "github.com/KarmaBeLike/time-tracker-api/docs"
+	"github.com/KarmaBeLike/time-tracker-api/internal/clock"
+	"github.com/KarmaBeLike/time-tracker-api/internal/external"
+	"github.com/KarmaBeLike/time-tracker-api/internal/handlers"
+	"github.com/KarmaBeLike/time-tracker-api/internal/metrics"
+	repositories "github.com/KarmaBeLike/time-tracker-api/internal/repository"
+	"github.com/KarmaBeLike/time-tracker-api/internal/service"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// Repositories are the storage the API runs on.
+type Repositories struct {
+	Users    repositories.UserRepository
+	Tasks    repositories.TaskRepository
+	Tags     repositories.TagRepository
+	Search   repositories.SearchRepository
+	Policies repositories.AutoStopPolicyRepository
+}
+
+// Dependencies is what the router is built from besides the configuration.
+// The app passes the Postgres repositories; tests can pass in-memory ones.
+type Dependencies struct {
+	Repositories
+	People  external.PersonInfoProvider
+	Clock   clock.Clock
+	Metrics *metrics.Metrics
+	// SchemaVersion and Checks feed the health endpoints.
+	SchemaVersion func(ctx context.Context) (string, error)
+	Checks        []service.HealthCheck
+}
+
+// Router serves the API. It keeps the admin token, which can be reloaded.
+type Router struct {
+	*gin.Engine
+	admin *handlers.AdminToken
+}
+
+// NewRouter wires services and handlers on top of deps and registers every
+// route with its middleware.
+func NewRouter(cfg *config.Config, deps Dependencies) *Router {
+	r := &Router{Engine: gin.New(), admin: handlers.NewAdminToken(cfg.AdminToken)}
+
+	// Recovery идёт последним, чтобы паника попала в лог запроса и в метрики
+	r.Use(otelgin.Middleware(cfg.TracingServiceName), handlers.RequestLogger(), deps.Metrics.Middleware(), handlers.ErrorHandler(), handlers.Recovery())
+	r.GET("/metrics", gin.WrapH(deps.Metrics.Handler()))
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("/swagger/doc.json")))
+
+	// Регистрация маршрутов
+	handlers.NewHealthHandler(service.NewHealthService(deps.SchemaVersion, deps.Checks...)).Routes(r.Engine, cfg)
+	handlers.NewUserHandler(service.NewUserService(deps.Users, deps.People), r.admin).Routes(r.Engine, cfg)
+	handlers.NewTaskHandler(service.NewTaskService(deps.Tasks, deps.Clock)).Routes(r.Engine, cfg)
+	handlers.NewTagHandler(service.NewTagService(deps.Tags)).Routes(r.Engine, cfg)
+	handlers.NewSearchHandler(service.NewSearchService(deps.Search), r.admin).Routes(r.Engine, cfg)
+	handlers.NewAutoStopPolicyHandler(service.NewAutoStopPolicyService(deps.Policies), r.admin).Routes(r.Engine, cfg)
+	handlers.NewLogLevelHandler(r.admin).Routes(r.Engine, cfg)
+	r.NoRoute(handlers.NotFound)
+
+	return r
+}
@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync/atomic"
+
+	"github.com/lib/pq"
+)
+
+// Connector opens Postgres connections with a DSN that can be swapped at
+// runtime, e.g. after the password was rotated. Connections already in the
+// pool keep working; only new ones use the new DSN.
+type Connector struct {
+	dsn atomic.Value
+}
+
+func NewConnector(dsn string) *Connector {
+	c := &Connector{}
+	c.dsn.Store(dsn)
+	return c
+}
+
+func (c *Connector) DSN() string {
+	return c.dsn.Load().(string)
+}
+
+func (c *Connector) SetDSN(dsn string) {
+	c.dsn.Store(dsn)
+}
+
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	connector, err := pq.NewConnector(c.DSN())
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
+
+func (c *Connector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
@@ -0,0 +1,240 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const migrationsDir = "migrations"
+
+// migrationVersions lists the versions of the *.up.sql files in the
+// migrations directory, in the order they are applied.
+func migrationVersions() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(migrationsDir, "*.up.sql"))
+	if err != nil {
+		return nil, fmt.Errorf("list migrations: %w", err)
+	}
+
+	versions := make([]string, 0, len(files))
+	for _, file := range files {
+		versions = append(versions, strings.TrimSuffix(filepath.Base(file), ".up.sql"))
+	}
+	sort.Strings(versions)
+	return versions, nil
+}
+
+func ensureMigrationsTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version VARCHAR(255) PRIMARY KEY,
+			applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+func appliedVersions(ctx context.Context, db *sql.DB) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// RunMigrations applies every *.up.sql file from the migrations directory
+// that is not yet recorded in schema_migrations, in version order.
+func RunMigrations(db *sql.DB) error {
+	ctx := context.Background()
+	if err := ensureMigrationsTable(ctx, db); err != nil {
+		return err
+	}
+
+	pending, err := PendingMigrations(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	for _, version := range pending {
+		// Чтение SQL файла
+		file, err := os.ReadFile(filepath.Join(migrationsDir, version+".up.sql"))
+		if err != nil {
+			return fmt.Errorf("read sql file: %w", err)
+		}
+
+		// Выполнение SQL команд
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("begin migration %s: %w", version, err)
+		}
+		if _, err := tx.Exec(string(file)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("execute sql file %s: %w", version, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version) VALUES ($1)", version); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("record migration %s: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("commit migration %s: %w", version, err)
+		}
+
+		log.Println("Applied migration", version)
+	}
+
+	log.Println("Migrations run successfully")
+	return nil
+}
+
+// RollbackMigrations reverts the last steps applied migrations with their
+// *.down.sql files, newest first, and returns the reverted versions.
+func RollbackMigrations(ctx context.Context, db *sql.DB, steps int) ([]string, error) {
+	if err := ensureMigrationsTable(ctx, db); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT version FROM schema_migrations ORDER BY version DESC LIMIT $1", steps)
+	if err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	var versions []string
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var reverted []string
+	for _, version := range versions {
+		file, err := os.ReadFile(filepath.Join(migrationsDir, version+".down.sql"))
+		if err != nil {
+			return reverted, fmt.Errorf("read sql file: %w", err)
+		}
+
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return reverted, fmt.Errorf("begin rollback %s: %w", version, err)
+		}
+		if _, err := tx.ExecContext(ctx, string(file)); err != nil {
+			tx.Rollback()
+			return reverted, fmt.Errorf("execute sql file %s: %w", version, err)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", version); err != nil {
+			tx.Rollback()
+			return reverted, fmt.Errorf("unrecord migration %s: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return reverted, fmt.Errorf("commit rollback %s: %w", version, err)
+		}
+
+		log.Println("Rolled back migration", version)
+		reverted = append(reverted, version)
+	}
+
+	return reverted, nil
+}
+
+// Migration is a migration file and when it was applied, if it was.
+type Migration struct {
+	Version   string
+	AppliedAt *time.Time
+}
+
+// MigrationStatus lists every migration in the migrations directory with the
+// time it was applied.
+func MigrationStatus(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	if err := ensureMigrationsTable(ctx, db); err != nil {
+		return nil, err
+	}
+	versions, err := migrationVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]time.Time)
+	for rows.Next() {
+		var version string
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(versions))
+	for _, version := range versions {
+		m := Migration{Version: version}
+		if at, ok := applied[version]; ok {
+			m.AppliedAt = &at
+		}
+		migrations = append(migrations, m)
+	}
+	return migrations, nil
+}
+
+// PendingMigrations returns the versions that exist in the migrations
+// directory but have not been applied yet.
+func PendingMigrations(ctx context.Context, db *sql.DB) ([]string, error) {
+	versions, err := migrationVersions()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []string
+	for _, version := range versions {
+		if !applied[version] {
+			pending = append(pending, version)
+		}
+	}
+	return pending, nil
+}
+
+// SchemaVersion returns the latest applied migration, or an empty string for
+// an empty database.
+func SchemaVersion(ctx context.Context, db *sql.DB) (string, error) {
+	var version sql.NullString
+	err := db.QueryRowContext(ctx, "SELECT MAX(version) FROM schema_migrations").Scan(&version)
+	if err != nil {
+		return "", fmt.Errorf("read schema version: %w", err)
+	}
+	return version.String, nil
+}
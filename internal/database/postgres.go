@@ -5,60 +5,140 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
-	"os"
+	"strings"
 	"time"
 
 	"github.com/KarmaBeLike/time-tracker-api/config"
-	_ "github.com/lib/pq"
+	"github.com/KarmaBeLike/time-tracker-api/pkg/logger"
+	"github.com/lib/pq"
 
 	"github.com/pkg/errors"
 )
 
 var DB *sql.DB
 
-func OpenDB(cfg *config.Config) (*sql.DB, error) {
-	psqlInfo := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-		cfg.Host,
-		cfg.DBPort,
-		cfg.DBUser,
-		cfg.DBPassword,
-		cfg.DBName)
-
-	db, err := sql.Open("postgres", psqlInfo)
-	if err != nil {
-		return nil, errors.Wrap(err, "open sql")
+const (
+	pingTimeout       = 5 * time.Second
+	initialRetryDelay = 500 * time.Millisecond
+	maxRetryDelay     = 10 * time.Second
+)
+
+// DSN returns the connection string for cfg: DB_URL as is if set, otherwise
+// a key/value DSN built from the individual settings.
+func DSN(cfg *config.Config) string {
+	if cfg.DBURL != "" {
+		return cfg.DBURL
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	sslMode := cfg.DBSSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
 
-	err = db.PingContext(ctx)
-	if err != nil {
-		return nil, errors.Wrap(err, "connection is not established")
+	params := []string{
+		"host=" + dsnValue(cfg.DBHost),
+		fmt.Sprintf("port=%d", cfg.DBPort),
+		"user=" + dsnValue(cfg.DBUser),
+		"password=" + dsnValue(cfg.DBPassword),
+		"dbname=" + dsnValue(cfg.DBName),
+		"sslmode=" + dsnValue(sslMode),
+	}
+	if cfg.DBSSLRootCert != "" {
+		params = append(params, "sslrootcert="+dsnValue(cfg.DBSSLRootCert))
+	}
+	if cfg.DBSSLCert != "" {
+		params = append(params, "sslcert="+dsnValue(cfg.DBSSLCert), "sslkey="+dsnValue(cfg.DBSSLKey))
 	}
+	return strings.Join(params, " ")
+}
 
-	log.Println("Connected to DB")
+// dsnValue quotes a value of a key/value DSN so that spaces and quotes in
+// passwords or paths survive.
+func dsnValue(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	return "'" + s + "'"
+}
 
-	return db, nil
+// OpenDB connects to the database and configures the connection pool. While
+// the server is unreachable the connection is retried with exponential
+// backoff for up to cfg.DBConnectTimeout, so the API can start before
+// Postgres does. A zero timeout tries only once.
+func OpenDB(cfg *config.Config) (*sql.DB, error) {
+	return Open(NewConnector(DSN(cfg)), cfg)
 }
 
-func RunMigrations(db *sql.DB) error {
-	filePath := "migrations/create_users_table.up.sql"
+// Open is OpenDB with a connector of the caller, so that the DSN can be
+// changed later.
+func Open(connector *Connector, cfg *config.Config) (*sql.DB, error) {
+	db := sql.OpenDB(connector)
+
+	db.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	db.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	db.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
 
-	// Чтение SQL файла
-	file, err := os.ReadFile(filePath)
+	var err error
+	if cfg.DBConnectTimeout > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.DBConnectTimeout)
+		defer cancel()
+		err = pingWithRetry(ctx, db)
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+		defer cancel()
+		err = db.PingContext(ctx)
+	}
 	if err != nil {
-		return fmt.Errorf("read sql file: %w", err)
+		db.Close()
+		return nil, errors.Wrap(err, "connection is not established")
 	}
 
-	// Выполнение SQL команд
-	_, err = db.Exec(string(file))
-	if err != nil {
-		return fmt.Errorf("execute sql file: %w", err)
+	logger.PrintInfo("Connected to DB", nil)
+
+	return db, nil
+}
+
+func pingWithRetry(ctx context.Context, db *sql.DB) error {
+	delay := initialRetryDelay
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+		err := db.PingContext(pingCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if !retryable(err) {
+			return err
+		}
+
+		logger.PrintInfo("Database is not available yet, retrying", map[string]any{
+			"attempt": attempt,
+			"retryIn": delay.String(),
+			"error":   err.Error(),
+		})
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
 	}
+}
 
-	log.Println("Migrations run successfully")
-	return nil
+// retryable reports whether a failed ping may succeed later. Errors returned
+// by a running server, such as a wrong password or a missing database, won't,
+// except while it is still starting up.
+func retryable(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "57P03" // cannot_connect_now
+	}
+	return true
 }
 
 func InitDB(cfg *config.Config) {
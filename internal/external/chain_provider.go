@@ -0,0 +1,54 @@
+package external
+
+import (
+	"context"
+	"errors"
+)
+
+// ChainProvider asks each provider in turn and returns the first successful
+// answer. A person is only reported as not found when every provider says so;
+// if any of them failed otherwise, the chain fails with those errors.
+type ChainProvider struct {
+	providers []PersonInfoProvider
+}
+
+func NewChainProvider(providers ...PersonInfoProvider) *ChainProvider {
+	return &ChainProvider{providers: providers}
+}
+
+func (p *ChainProvider) GetPersonInfo(ctx context.Context, passportNumber string) (*PeopleResponse, error) {
+	var errs []error
+	for _, provider := range p.providers {
+		person, err := provider.GetPersonInfo(ctx, passportNumber)
+		if err == nil {
+			return person, nil
+		}
+		// Отказ источника важнее ответа «не найден» от другого
+		if !errors.Is(err, ErrPersonNotFound) {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil, ErrPersonNotFound
+	}
+	return nil, errors.Join(errs...)
+}
+
+// Ping succeeds if any provider of the chain that can be pinged is reachable,
+// since the chain can still answer through it. Providers without a Ping are
+// skipped.
+func (p *ChainProvider) Ping(ctx context.Context) error {
+	var errs []error
+	for _, provider := range p.providers {
+		pinger, ok := provider.(Pinger)
+		if !ok {
+			continue
+		}
+		err := pinger.Ping(ctx)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,73 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// stubProvider answers with a fixed person or error.
+type stubProvider struct {
+	person *PeopleResponse
+	err    error
+}
+
+func (p stubProvider) GetPersonInfo(ctx context.Context, passportNumber string) (*PeopleResponse, error) {
+	return p.person, p.err
+}
+
+// stubPinger is a provider that can be pinged.
+type stubPinger struct {
+	stubProvider
+	pingErr error
+}
+
+func (p stubPinger) Ping(ctx context.Context) error {
+	return p.pingErr
+}
+
+func TestChainProviderGetPersonInfo(t *testing.T) {
+	ctx := context.Background()
+	outage := errors.New("people API is down")
+	notFound := stubProvider{err: ErrPersonNotFound}
+
+	person, err := NewChainProvider(notFound, stubProvider{person: &PeopleResponse{Name: "Иван"}}).GetPersonInfo(ctx, "1234 567890")
+	if err != nil || person.Name != "Иван" {
+		t.Fatalf("GetPersonInfo = %+v, %v; want the second provider's answer", person, err)
+	}
+
+	if _, err := NewChainProvider(notFound, notFound).GetPersonInfo(ctx, "1234 567890"); !errors.Is(err, ErrPersonNotFound) {
+		t.Fatalf("GetPersonInfo when nobody knows the person: %v, want ErrPersonNotFound", err)
+	}
+
+	// Не найден в одном источнике, а другой недоступен — это отказ, а не «не найден»
+	for _, providers := range [][]PersonInfoProvider{
+		{stubProvider{err: outage}, notFound},
+		{notFound, stubProvider{err: outage}},
+	} {
+		_, err := NewChainProvider(providers...).GetPersonInfo(ctx, "1234 567890")
+		if !errors.Is(err, outage) || errors.Is(err, ErrPersonNotFound) {
+			t.Fatalf("GetPersonInfo with an outage: %v, want only the outage", err)
+		}
+	}
+}
+
+func TestChainProviderPing(t *testing.T) {
+	ctx := context.Background()
+	outage := errors.New("people API is down")
+
+	// Источники без Ping не делают цепочку здоровой
+	if err := NewChainProvider(notPingable(), stubPinger{pingErr: outage}).Ping(ctx); !errors.Is(err, outage) {
+		t.Fatalf("Ping = %v, want the outage", err)
+	}
+	if err := NewChainProvider(stubPinger{pingErr: outage}, stubPinger{}).Ping(ctx); err != nil {
+		t.Fatalf("Ping with one reachable provider = %v", err)
+	}
+	if err := NewChainProvider(notPingable()).Ping(ctx); err != nil {
+		t.Fatalf("Ping without pingable providers = %v", err)
+	}
+}
+
+func notPingable() PersonInfoProvider {
+	return stubProvider{err: ErrPersonNotFound}
+}
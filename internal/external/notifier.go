@@ -0,0 +1,110 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+	"github.com/KarmaBeLike/time-tracker-api/pkg/logger"
+)
+
+// Notifier delivers notices about auto-stopped timers to their owners.
+type Notifier interface {
+	NotifyAutoStopped(ctx context.Context, notice models.AutoStopNotice) error
+}
+
+// NewNotifier returns a webhook notifier when webhookURL is set and a
+// notifier that only writes to the log otherwise.
+func NewNotifier(webhookURL string) Notifier {
+	if webhookURL == "" {
+		return LogNotifier{}
+	}
+	return NewWebhookNotifier(webhookURL)
+}
+
+// LogNotifier records notices in the application log.
+type LogNotifier struct{}
+
+func (LogNotifier) NotifyAutoStopped(_ context.Context, notice models.AutoStopNotice) error {
+	logger.PrintInfo("Timer auto-stopped", map[string]any{
+		"userId":    notice.UserID,
+		"worklogId": notice.WorklogID,
+		"taskId":    notice.TaskID,
+		"endTime":   notice.EndTime,
+	})
+	return nil
+}
+
+// WebhookNotifier POSTs notices as JSON to a URL, e.g. a chat integration
+// that forwards them to the user.
+type WebhookNotifier struct {
+	URL    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{URL: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (n *WebhookNotifier) NotifyAutoStopped(ctx context.Context, notice models.AutoStopNotice) error {
+	body, err := json.Marshal(struct {
+		Event string `json:"event"`
+		models.AutoStopNotice
+	}{"timer.auto_stopped", notice})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("failed to deliver notice, status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ReloadableNotifier delegates to the notifier for its current webhook URL,
+// which can be changed at runtime, e.g. when the URL carries a rotated token.
+type ReloadableNotifier struct {
+	state atomic.Value
+}
+
+type notifierState struct {
+	webhookURL string
+	notifier   Notifier
+}
+
+func NewReloadableNotifier(webhookURL string) *ReloadableNotifier {
+	n := &ReloadableNotifier{}
+	n.SetWebhookURL(webhookURL)
+	return n
+}
+
+// SetWebhookURL switches to a notifier for webhookURL and reports whether it
+// differs from the previous one.
+func (n *ReloadableNotifier) SetWebhookURL(webhookURL string) bool {
+	if state, ok := n.state.Load().(notifierState); ok && state.webhookURL == webhookURL {
+		return false
+	}
+	n.state.Store(notifierState{webhookURL: webhookURL, notifier: NewNotifier(webhookURL)})
+	return true
+}
+
+func (n *ReloadableNotifier) NotifyAutoStopped(ctx context.Context, notice models.AutoStopNotice) error {
+	return n.state.Load().(notifierState).notifier.NotifyAutoStopped(ctx, notice)
+}
@@ -1,9 +1,16 @@
 package external
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 type PeopleAPIClient struct {
@@ -21,14 +28,29 @@ func NewPeopleAPIClient(baseURL string) *PeopleAPIClient {
 	return &PeopleAPIClient{BaseURL: baseURL}
 }
 
-func (client *PeopleAPIClient) GetPersonInfo(passportNumber string) (*PeopleResponse, error) {
-	url := fmt.Sprintf("%s/info?passportNumber=%s", client.BaseURL, passportNumber)
-	resp, err := http.Get(url)
+func (client *PeopleAPIClient) GetPersonInfo(ctx context.Context, passportNumber string) (_ *PeopleResponse, err error) {
+	ctx, span := tracing.Start(ctx, "PeopleAPIClient.GetPersonInfo", attribute.String("peer.service", "people-api"))
+	defer func() { tracing.End(span, err) }()
+
+	// Номер паспорта содержит пробел, поэтому параметры кодируются
+	query := url.Values{"passportNumber": {passportNumber}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, client.BaseURL+"/info?"+query, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Передаём контекст трассировки в People API
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrPersonNotFound
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("failed to get person info, status code: %d", resp.StatusCode)
 	}
@@ -41,3 +63,22 @@ func (client *PeopleAPIClient) GetPersonInfo(passportNumber string) (*PeopleResp
 
 	return &peopleResponse, nil
 }
+
+// Ping checks that the People API answers at all. Client errors count as
+// reachable; only network failures and 5xx responses don't.
+func (client *PeopleAPIClient) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, client.BaseURL+"/info", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("people API is unhealthy, status code: %d", resp.StatusCode)
+	}
+	return nil
+}
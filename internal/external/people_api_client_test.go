@@ -0,0 +1,39 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPeopleAPIClientGetPersonInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/info" {
+			t.Errorf("path = %q, want /info", r.URL.Path)
+		}
+		switch r.URL.Query().Get("passportNumber") {
+		case "1234 567890":
+			json.NewEncoder(w).Encode(PeopleResponse{Surname: "Иванов", Name: "Иван"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := NewPeopleAPIClient(server.URL)
+
+	// Пробел в номере паспорта должен дойти до People API как есть
+	person, err := client.GetPersonInfo(context.Background(), "1234 567890")
+	if err != nil {
+		t.Fatalf("GetPersonInfo: %v", err)
+	}
+	if person.Surname != "Иванов" || person.Name != "Иван" {
+		t.Fatalf("GetPersonInfo = %+v", person)
+	}
+
+	if _, err := client.GetPersonInfo(context.Background(), "1234 567891&x=1"); !errors.Is(err, ErrPersonNotFound) {
+		t.Fatalf("GetPersonInfo of an unknown passport: %v, want ErrPersonNotFound", err)
+	}
+}
@@ -0,0 +1,66 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/KarmaBeLike/time-tracker-api/config"
+)
+
+// ErrPersonNotFound is returned by a provider that has no record for the
+// requested passport number.
+var ErrPersonNotFound = errors.New("person not found")
+
+// PersonInfoProvider resolves personal data for a passport number.
+type PersonInfoProvider interface {
+	GetPersonInfo(ctx context.Context, passportNumber string) (*PeopleResponse, error)
+}
+
+const (
+	ProviderHTTP  = "http"
+	ProviderFile  = "file"
+	ProviderChain = "chain"
+)
+
+// NewPersonInfoProvider builds the provider selected by cfg.PeopleProvider.
+// An empty value keeps the People API client for backwards compatibility.
+func NewPersonInfoProvider(cfg *config.Config) (PersonInfoProvider, error) {
+	switch strings.ToLower(cfg.PeopleProvider) {
+	case "", ProviderHTTP:
+		return NewPeopleAPIClient(cfg.PeopleAPIBaseURL), nil
+	case ProviderFile:
+		return NewStaticFileProvider(cfg.PeopleFilePath)
+	case ProviderChain:
+		var providers []PersonInfoProvider
+		for _, name := range strings.Split(cfg.PeopleProviderChain, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if name == ProviderChain {
+				return nil, errors.New("people provider chain can't contain itself")
+			}
+			sub := *cfg
+			sub.PeopleProvider = name
+			provider, err := NewPersonInfoProvider(&sub)
+			if err != nil {
+				return nil, err
+			}
+			providers = append(providers, provider)
+		}
+		if len(providers) == 0 {
+			return nil, errors.New("people provider chain is empty")
+		}
+		return NewChainProvider(providers...), nil
+	default:
+		return nil, fmt.Errorf("unknown people provider %q", cfg.PeopleProvider)
+	}
+}
+
+// Pinger is implemented by providers that can report whether their data
+// source is reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
@@ -0,0 +1,36 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// StaticFileProvider serves person info from a JSON file that maps passport
+// numbers to records, e.g. {"1234 567890": {"name": "Ivan", ...}}.
+type StaticFileProvider struct {
+	people map[string]PeopleResponse
+}
+
+func NewStaticFileProvider(path string) (*StaticFileProvider, error) {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read people file: %w", err)
+	}
+
+	people := make(map[string]PeopleResponse)
+	if err := json.Unmarshal(file, &people); err != nil {
+		return nil, fmt.Errorf("decode people file: %w", err)
+	}
+
+	return &StaticFileProvider{people: people}, nil
+}
+
+func (p *StaticFileProvider) GetPersonInfo(_ context.Context, passportNumber string) (*PeopleResponse, error) {
+	person, ok := p.people[passportNumber]
+	if !ok {
+		return nil, ErrPersonNotFound
+	}
+	return &person, nil
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminToken guards the admin features: purging users, the auto-stop policies,
+// the log level and searches over every user. Each handler offering one checks
+// it; requests carry it as a bearer token, and while it is empty the features
+// are off.
+type AdminToken struct {
+	token atomic.Value // string
+}
+
+func NewAdminToken(token string) *AdminToken {
+	a := &AdminToken{}
+	a.Set(token)
+	return a
+}
+
+// Set replaces the token and reports whether it changed.
+func (a *AdminToken) Set(token string) bool {
+	if current, ok := a.token.Load().(string); ok && current == token {
+		return false
+	}
+	a.token.Store(token)
+	return true
+}
+
+// Authorized reports whether the request carries the token.
+func (a *AdminToken) Authorized(c *gin.Context) bool {
+	token := a.token.Load().(string)
+	given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	return ok && token != "" && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
+
+// Require is a middleware that rejects requests without the token.
+func (a *AdminToken) Require(c *gin.Context) {
+	if !a.Authorized(c) {
+		abortUnauthorized(c)
+		return
+	}
+	c.Next()
+}
+
+func abortUnauthorized(c *gin.Context) {
+	c.Header("WWW-Authenticate", "Bearer")
+	c.Error(errUnauthorized)
+	c.Abort()
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/KarmaBeLike/time-tracker-api/config"
+	"github.com/KarmaBeLike/time-tracker-api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type AutoStopPolicyHandler struct {
+	policyService service.AutoStopPolicyService
+	admin         *AdminToken
+}
+
+func NewAutoStopPolicyHandler(policyService service.AutoStopPolicyService, admin *AdminToken) *AutoStopPolicyHandler {
+	return &AutoStopPolicyHandler{policyService: policyService, admin: admin}
+}
+
+func (h *AutoStopPolicyHandler) Routes(router *gin.Engine, cfg *config.Config) {
+	policies := router.Group("/admin/auto-stop-policies", h.admin.Require)
+	{
+		policies.GET("/", h.GetPolicies)                      // @summary Get auto-stop policies
+		policies.PUT("/users/:userId", h.SaveUserPolicy)      // @summary Set the auto-stop policy of a user
+		policies.DELETE("/users/:userId", h.DeleteUserPolicy) // @summary Remove the auto-stop policy of a user
+		policies.PUT("/teams/:team", h.SaveTeamPolicy)        // @summary Set the auto-stop policy of a team
+		policies.DELETE("/teams/:team", h.DeleteTeamPolicy)   // @summary Remove the auto-stop policy of a team
+	}
+}
+
+// @Summary Get auto-stop policies
+// @Description Get the auto-stop policies configured for users and teams. Timers of everyone else follow the server default.
+// @Tags Admin
+// @Security AdminToken
+// @Produce  json
+// @Success 200 {object} object{data=[]models.AutoStopPolicy}
+// @Failure 401 {object} Problem
+// @Router /admin/auto-stop-policies/ [get]
+func (h *AutoStopPolicyHandler) GetPolicies(c *gin.Context) {
+	policies, err := h.policyService.GetPolicies(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": policies})
+}
+
+// @Summary Set the auto-stop policy of a user
+// @Description Timers of the user running longer than maxDuration are stopped after cap, or at the last activity when stopAt is last_activity. Overrides the team policy.
+// @Tags Admin
+// @Security AdminToken
+// @Accept  json
+// @Produce  json
+// @Param userId path int true "User ID"
+// @Param policy body AutoStopPolicyRequest true "Policy"
+// @Success 200 {object} object{status=string,policy=models.AutoStopPolicy}
+// @Failure 400 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 401 {object} Problem
+// @Router /admin/auto-stop-policies/users/{userId} [put]
+func (h *AutoStopPolicyHandler) SaveUserPolicy(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	var req AutoStopPolicyRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	policy, err := h.policyService.SaveUserPolicy(c.Request.Context(), userId, req.toModel())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "saved", "policy": policy})
+}
+
+// @Summary Remove the auto-stop policy of a user
+// @Tags Admin
+// @Security AdminToken
+// @Param userId path int true "User ID"
+// @Success 200
+// @Failure 404 {object} Problem
+// @Failure 401 {object} Problem
+// @Router /admin/auto-stop-policies/users/{userId} [delete]
+func (h *AutoStopPolicyHandler) DeleteUserPolicy(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	if err := h.policyService.DeleteUserPolicy(c.Request.Context(), userId); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "deleted", "userId": userId})
+}
+
+// @Summary Set the auto-stop policy of a team
+// @Description Applies to every user whose team matches and who has no policy of their own.
+// @Tags Admin
+// @Security AdminToken
+// @Accept  json
+// @Produce  json
+// @Param team path string true "Team"
+// @Param policy body AutoStopPolicyRequest true "Policy"
+// @Success 200 {object} object{status=string,policy=models.AutoStopPolicy}
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Router /admin/auto-stop-policies/teams/{team} [put]
+func (h *AutoStopPolicyHandler) SaveTeamPolicy(c *gin.Context) {
+	team, ok := teamParam(c)
+	if !ok {
+		return
+	}
+
+	var req AutoStopPolicyRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	policy, err := h.policyService.SaveTeamPolicy(c.Request.Context(), team, req.toModel())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "saved", "policy": policy})
+}
+
+// @Summary Remove the auto-stop policy of a team
+// @Tags Admin
+// @Security AdminToken
+// @Param team path string true "Team"
+// @Success 200
+// @Failure 400 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 401 {object} Problem
+// @Router /admin/auto-stop-policies/teams/{team} [delete]
+func (h *AutoStopPolicyHandler) DeleteTeamPolicy(c *gin.Context) {
+	team, ok := teamParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.policyService.DeleteTeamPolicy(c.Request.Context(), team); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "deleted", "team": team})
+}
+
+// teamParam returns the team of the path. It must fit the team columns,
+// like UpdateUserRequest.Team.
+func teamParam(c *gin.Context) (string, bool) {
+	team := c.Param("team")
+	if n := utf8.RuneCountInString(team); n == 0 || n > 100 {
+		c.Error(errInvalidTeam)
+		return "", false
+	}
+	return team, true
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details body. Code is a stable,
+// machine-readable identifier of the error.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	// Errors lists field-level problems of a validation error.
+	Errors []service.FieldError `json:"errors,omitempty"`
+}
+
+var (
+	errInvalidUserID    = service.NewValidationError("invalid_user_id", "invalid user ID")
+	errInvalidTaskID    = service.NewValidationError("invalid_task_id", "invalid task ID")
+	errInvalidWorklogID = service.NewValidationError("invalid_worklog_id", "invalid worklog ID")
+	errInvalidTagID     = service.NewValidationError("invalid_tag_id", "invalid tag ID")
+	errInvalidTeam      = service.NewValidationError("invalid_team", "team must be 1 to 100 characters long")
+	errInvalidBody      = service.NewValidationError("invalid_body", "invalid request body")
+	errInvalidQuery     = service.NewValidationError("invalid_query", "invalid query parameters")
+	errRouteNotFound    = service.NewNotFoundError("route_not_found", "route not found")
+	errUnauthorized     = service.NewUnauthorizedError("unauthorized", "missing or invalid admin token")
+)
+
+var kindStatus = map[service.ErrorKind]int{
+	service.KindValidation:   http.StatusBadRequest,
+	service.KindNotFound:     http.StatusNotFound,
+	service.KindConflict:     http.StatusConflict,
+	service.KindUnavailable:  http.StatusServiceUnavailable,
+	service.KindUnauthorized: http.StatusUnauthorized,
+}
+
+// ErrorHandler renders the last error attached to the context with c.Error
+// as a problem+json response. Errors that are not *service.Error are logged
+// and reported as a generic internal error, so driver messages never leak.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		problem := Problem{
+			Status:   http.StatusInternalServerError,
+			Instance: c.Request.URL.Path,
+			Code:     "internal_error",
+			Detail:   "internal server error",
+		}
+
+		var domainErr *service.Error
+		if errors.As(err, &domainErr) {
+			if status, ok := kindStatus[domainErr.Kind]; ok {
+				problem.Status = status
+			}
+			problem.Code = domainErr.Code
+			problem.Detail = domainErr.Message
+			problem.Errors = domainErr.Fields
+		}
+		if problem.Status >= http.StatusInternalServerError {
+			requestLogger(c).PrintError(err, map[string]any{"code": problem.Code})
+		}
+
+		abortWithProblem(c, problem)
+	}
+}
+
+// Recovery turns a panic in the handlers after it into a 500 problem. The
+// panic is logged with its stack by the request logger, so it has to come
+// after RequestLogger.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			// Клиент отключился, отвечать некому
+			if r == http.ErrAbortHandler {
+				c.Abort()
+				return
+			}
+
+			requestLogger(c).PrintError(fmt.Errorf("panic: %v", r), map[string]any{"stack": string(debug.Stack())})
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			abortWithProblem(c, Problem{
+				Status:   http.StatusInternalServerError,
+				Instance: c.Request.URL.Path,
+				Code:     "internal_error",
+				Detail:   "internal server error",
+			})
+		}()
+		c.Next()
+	}
+}
+
+func abortWithProblem(c *gin.Context, problem Problem) {
+	problem.Type = "/problems/" + problem.Code
+	problem.Title = http.StatusText(problem.Status)
+
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(problem.Status, problem)
+}
+
+// NotFound is a gin NoRoute handler that reports unknown routes as problems.
+func NotFound(c *gin.Context) {
+	c.Error(errRouteNotFound)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/KarmaBeLike/time-tracker-api/config"
+	"github.com/KarmaBeLike/time-tracker-api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout bounds how long a readiness probe waits for its checks.
+const readinessTimeout = 3 * time.Second
+
+type HealthHandler struct {
+	healthService service.HealthService
+}
+
+func NewHealthHandler(healthService service.HealthService) *HealthHandler {
+	return &HealthHandler{healthService: healthService}
+}
+
+func (h *HealthHandler) Routes(router *gin.Engine, cfg *config.Config) {
+	router.GET("/healthz", h.Live)    // @summary Liveness probe
+	router.GET("/readyz", h.Ready)    // @summary Readiness probe
+	router.GET("/version", h.Version) // @summary Build and schema version
+}
+
+// @Summary Liveness probe
+// @Description Reports that the process is up. Doesn't check any dependency.
+// @Tags Health
+// @Produce json
+// @Success 200
+// @Router /healthz [get]
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// @Summary Readiness probe
+// @Description Checks database connectivity, pending migrations and People API reachability.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} service.Readiness
+// @Failure 503 {object} service.Readiness
+// @Router /readyz [get]
+func (h *HealthHandler) Ready(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	readiness := h.healthService.Ready(ctx)
+	status := http.StatusOK
+	if !readiness.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	for name, result := range readiness.Checks {
+		if result.Err != nil {
+			requestLogger(c).PrintError(result.Err, map[string]any{"check": name, "code": result.Code})
+		}
+	}
+	c.JSON(status, readiness)
+}
+
+// @Summary Build and schema version
+// @Tags Health
+// @Produce json
+// @Success 200 {object} service.VersionInfo
+// @Router /version [get]
+func (h *HealthHandler) Version(c *gin.Context) {
+	version, err := h.healthService.Version(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, version)
+}
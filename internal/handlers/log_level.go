@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/KarmaBeLike/time-tracker-api/config"
+	"github.com/KarmaBeLike/time-tracker-api/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// LogLevelHandler serves the log level routes. They are always registered, so
+// that a token set on SIGHUP takes effect; without one they answer 401.
+type LogLevelHandler struct {
+	admin *AdminToken
+}
+
+func NewLogLevelHandler(admin *AdminToken) *LogLevelHandler {
+	return &LogLevelHandler{admin: admin}
+}
+
+func (h *LogLevelHandler) Routes(router *gin.Engine, cfg *config.Config) {
+	admin := router.Group("/admin", h.admin.Require)
+	{
+		admin.GET("/log-level", h.GetLogLevel) // @summary Get the log level
+		admin.PUT("/log-level", h.SetLogLevel) // @summary Change the log level
+	}
+}
+
+// @Summary Get the log level
+// @Description Get the minimum level of lines written to the log.
+// @Tags Admin
+// @Produce  json
+// @Security AdminToken
+// @Success 200
+// @Failure 401 {object} Problem
+// @Router /admin/log-level [get]
+func (h *LogLevelHandler) GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"level": strings.ToLower(logger.GetLevel().String())})
+}
+
+// @Summary Change the log level
+// @Description Change the minimum log level at runtime, e.g. to debug an issue in production. Logging can't be turned off this way. The change is not persisted and is lost on restart.
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Security AdminToken
+// @Param level body LogLevelRequest true "Log level"
+// @Success 200
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Router /admin/log-level [put]
+func (h *LogLevelHandler) SetLogLevel(c *gin.Context) {
+	var req LogLevelRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	level, err := logger.ParseLevel(req.Level)
+	if err != nil {
+		c.Error(errInvalidBody)
+		return
+	}
+
+	previous := logger.GetLevel()
+	logger.SetLevel(level)
+	requestLogger(c).PrintInfo("Log level changed", map[string]any{
+		"from": strings.ToLower(previous.String()),
+		"to":   strings.ToLower(level.String()),
+	})
+
+	c.JSON(http.StatusOK, gin.H{"level": strings.ToLower(level.String())})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/KarmaBeLike/time-tracker-api/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+
+	requestIDKey = "requestId"
+	loggerKey    = "logger"
+
+	maxRequestIDLength = 128
+)
+
+// RequestLogger assigns every request an ID, honouring a sane incoming
+// X-Request-ID, and echoes it in the response. Handlers get a logger that
+// tags each line with the request ID and route, both from the gin context
+// and from the request context. When the request is done an access line with
+// status and latency is written.
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		c.Header(RequestIDHeader, requestID)
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		fields := map[string]any{
+			"requestId": requestID,
+			"method":    c.Request.Method,
+			"route":     route,
+		}
+		if sc := trace.SpanContextFromContext(c.Request.Context()); sc.HasTraceID() {
+			fields["traceId"] = sc.TraceID().String()
+		}
+		log := logger.Default().With(fields)
+
+		c.Set(requestIDKey, requestID)
+		c.Set(loggerKey, log)
+		c.Request = c.Request.WithContext(logger.NewContext(c.Request.Context(), log))
+
+		c.Next()
+
+		properties := map[string]any{
+			"status":    c.Writer.Status(),
+			"latencyMs": float64(time.Since(start).Microseconds()) / 1000,
+			"path":      c.Request.URL.Path,
+			"clientIp":  c.ClientIP(),
+		}
+		if errs := c.Errors.ByType(gin.ErrorTypePrivate); len(errs) > 0 {
+			properties["errors"] = errs.Errors()
+		}
+		log.PrintInfo("Request handled", properties)
+	}
+}
+
+// requestLogger returns the logger of the current request.
+func requestLogger(c *gin.Context) *logger.Logger {
+	if log, ok := c.Get(loggerKey); ok {
+		return log.(*logger.Logger)
+	}
+	return logger.Default()
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+)
+
+// Request DTOs bound and validated by the handlers. Validation rules live in
+// the binding tags; see validation.go for the custom ones.
+
+type CreateUserRequest struct {
+	PassportNumber string `json:"passportNumber" binding:"required,passport"`
+}
+
+type UpdateUserRequest struct {
+	Name           string `json:"name" binding:"required,max=100"`
+	Surname        string `json:"surname" binding:"required,max=100"`
+	Patronymic     string `json:"patronymic" binding:"max=100"`
+	PassportNumber string `json:"passportNumber" binding:"required,passport"`
+	Address        string `json:"address" binding:"max=1000"`
+	Team           string `json:"team" binding:"max=100"`
+}
+
+// userPatchRules are applied to the non-null members of a user merge patch.
+var userPatchRules = map[string]string{
+	"name":           "max=100",
+	"surname":        "max=100",
+	"patronymic":     "max=100",
+	"passportNumber": "omitempty,passport",
+	"address":        "max=1000",
+	"team":           "max=100",
+}
+
+type GetUsersQuery struct {
+	Name           string    `form:"name" binding:"max=100"`
+	Surname        string    `form:"surname" binding:"max=100"`
+	Patronymic     string    `form:"patronymic" binding:"max=100"`
+	Address        string    `form:"address" binding:"max=1000"`
+	PassportNumber string    `form:"passportNumber" binding:"omitempty,passport"`
+	Search         string    `form:"q" binding:"max=300"`
+	CreatedFrom    time.Time `form:"createdFrom" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedTo      time.Time `form:"createdTo" time_format:"2006-01-02T15:04:05Z07:00" binding:"omitempty,gtefield=CreatedFrom"`
+	Active         *bool     `form:"active"`
+	IncludeDeleted bool      `form:"includeDeleted"`
+	Sort           string    `form:"sort" binding:"omitempty,oneof=id name surname patronymic passportNumber address createdAt relevance"`
+	Order          string    `form:"order" binding:"omitempty,oneof=asc desc"`
+	Page           int       `form:"page,default=1" binding:"min=1"`
+	Limit          int       `form:"limit,default=10" binding:"min=1,max=100"`
+	Cursor         string    `form:"cursor" binding:"max=1000"`
+}
+
+type StartTaskRequest struct {
+	Note   string `json:"note" binding:"max=1000"`
+	TagIDs []int  `json:"tagIds" binding:"max=20"`
+}
+
+type StopTaskRequest struct {
+	// Note is added to the one given at the start.
+	Note string `json:"note" binding:"max=1000"`
+}
+
+type HeartbeatRequest struct {
+	// At is when the client last saw user input; defaults to now.
+	At *time.Time `json:"at"`
+}
+
+type ResolveIdleRequest struct {
+	Action string `json:"action" binding:"required,oneof=accept discard"`
+	// Resume starts a new timer for the same task after an accepted trim of
+	// a running worklog.
+	Resume bool `json:"resume"`
+}
+
+type GetWorklogsQuery struct {
+	StartDate time.Time `form:"startDate" time_format:"2006-01-02"`
+	EndDate   time.Time `form:"endDate" time_format:"2006-01-02" binding:"omitempty,gtefield=StartDate"`
+	Tags      []string  `form:"tag" binding:"max=20,dive,max=50"`
+	GroupBy   string    `form:"groupBy" binding:"omitempty,oneof=task tag"`
+}
+
+type SearchQuery struct {
+	Query string `form:"q" binding:"required,max=300"`
+	// UserID is required unless All asks for every user's worklogs.
+	UserID    int       `form:"userId" binding:"required_without=All,excluded_with=All,min=0"`
+	All       bool      `form:"all"`
+	StartDate time.Time `form:"startDate" time_format:"2006-01-02"`
+	EndDate   time.Time `form:"endDate" time_format:"2006-01-02" binding:"omitempty,gtefield=StartDate"`
+	Limit     int       `form:"limit,default=20" binding:"min=1,max=100"`
+}
+
+type AutoStopPolicyRequest struct {
+	MaxDuration models.Duration `json:"maxDuration" binding:"required,mindur=1m" swaggertype:"string" example:"12h"`
+	StopAt      string          `json:"stopAt" binding:"required,oneof=cap last_activity"`
+	Cap         models.Duration `json:"cap" binding:"required,mindur=1m" swaggertype:"string" example:"8h"`
+}
+
+func (r AutoStopPolicyRequest) toModel() models.AutoStopPolicy {
+	return models.AutoStopPolicy{MaxDuration: r.MaxDuration, StopAt: r.StopAt, Cap: r.Cap}
+}
+
+type TagRequest struct {
+	Name string `json:"name" binding:"required,max=50"`
+	// Color is a hex RGB color like "#1e88e5"; defaults to grey.
+	Color string `json:"color" binding:"omitempty,len=7,hexcolor"`
+}
+
+func (r TagRequest) toModel() models.Tag {
+	return models.Tag{Name: r.Name, Color: r.Color}
+}
+
+type SetTagsRequest struct {
+	// TagIDs replace the current tags; an empty list removes them all.
+	TagIDs []int `json:"tagIds" binding:"required,max=20"`
+}
+
+type LogLevelRequest struct {
+	Level string `json:"level" binding:"required,oneof=debug info error fatal"`
+}
@@ -0,0 +1,672 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KarmaBeLike/time-tracker-api/config"
+	"github.com/KarmaBeLike/time-tracker-api/internal/app"
+	"github.com/KarmaBeLike/time-tracker-api/internal/clock"
+	postgres "github.com/KarmaBeLike/time-tracker-api/internal/database"
+	"github.com/KarmaBeLike/time-tracker-api/internal/external"
+	"github.com/KarmaBeLike/time-tracker-api/internal/metrics"
+	repositories "github.com/KarmaBeLike/time-tracker-api/internal/repository"
+	"github.com/KarmaBeLike/time-tracker-api/internal/service"
+	"github.com/KarmaBeLike/time-tracker-api/internal/testdb"
+	"github.com/KarmaBeLike/time-tracker-api/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	logger.Configure(logger.Options{Level: logger.LevelOff, Output: io.Discard})
+	os.Exit(testdb.Main(m))
+}
+
+// backend is the storage the handlers under test run on.
+type backend struct {
+	users    repositories.UserRepository
+	tasks    repositories.TaskRepository
+	policies repositories.AutoStopPolicyRepository
+	tags     repositories.TagRepository
+	search   repositories.SearchRepository
+	addTask  func(t *testing.T, name string) int
+	// version and checks feed the health endpoints.
+	version func(ctx context.Context) (string, error)
+	checks  []service.HealthCheck
+}
+
+func memoryBackend(t *testing.T) backend {
+	store := repositories.NewMemoryStore()
+	return backend{
+		users:    repositories.NewMemoryUserRepository(store),
+		tasks:    repositories.NewMemoryTaskRepository(store),
+		policies: repositories.NewMemoryAutoStopPolicyRepository(store),
+		tags:     repositories.NewMemoryTagRepository(store),
+		search:   repositories.NewMemorySearchRepository(store),
+		addTask: func(t *testing.T, name string) int {
+			return store.AddTask(name, "")
+		},
+		version: func(ctx context.Context) (string, error) { return "memory", nil },
+	}
+}
+
+func postgresBackend(t *testing.T) backend {
+	db := testdb.Open(t)
+	testdb.Reset(t, db)
+	return backend{
+		users:    repositories.NewUserRepository(db, repositories.Timeouts{}),
+		tasks:    repositories.NewTaskRepository(db, repositories.Timeouts{}),
+		policies: repositories.NewAutoStopPolicyRepository(db, repositories.Timeouts{}),
+		tags:     repositories.NewTagRepository(db, repositories.Timeouts{}),
+		search:   repositories.NewSearchRepository(db, repositories.Timeouts{}),
+		addTask: func(t *testing.T, name string) int {
+			return testdb.AddTask(t, db, name, "")
+		},
+		version: func(ctx context.Context) (string, error) { return postgres.SchemaVersion(ctx, db) },
+		checks:  []service.HealthCheck{{Name: "database", Check: db.PingContext}},
+	}
+}
+
+// people is a People API with a fixed set of people.
+type people map[string]external.PeopleResponse
+
+func (p people) GetPersonInfo(_ context.Context, passportNumber string) (*external.PeopleResponse, error) {
+	person, ok := p[passportNumber]
+	if !ok {
+		return nil, external.ErrPersonNotFound
+	}
+	return &person, nil
+}
+
+const testAdminToken = "admin-secret"
+
+// adminHeader authorizes requests to the admin features.
+func adminHeader() http.Header {
+	return http.Header{"Authorization": {"Bearer " + testAdminToken}}
+}
+
+var testPeople = people{
+	"1234 567890": {Surname: "Ivanov", Name: "Ivan", Patronymic: "Ivanovich", Address: "Moscow"},
+	"1111 111111": {Surname: "Petrov", Name: "Petr", Patronymic: "Petrovich", Address: "Kazan"},
+}
+
+// newRouter builds the app's router on top of the backend.
+func newRouter(b backend, clk clock.Clock) *gin.Engine {
+	return newAppRouter(b, clk, &config.Config{AdminToken: testAdminToken})
+}
+
+func newAppRouter(b backend, clk clock.Clock, cfg *config.Config) *gin.Engine {
+	return app.NewRouter(cfg, app.Dependencies{
+		Repositories: app.Repositories{
+			Users:    b.users,
+			Tasks:    b.tasks,
+			Tags:     b.tags,
+			Search:   b.search,
+			Policies: b.policies,
+		},
+		People:        testPeople,
+		Clock:         clk,
+		Metrics:       metrics.New(),
+		SchemaVersion: b.version,
+		Checks:        b.checks,
+	}).Engine
+}
+
+// client sends requests to a router and checks the responses.
+type client struct {
+	t      *testing.T
+	router *gin.Engine
+	// header is sent with every request.
+	header http.Header
+}
+
+// do sends a request with body encoded as JSON, unless it is nil or already
+// a string, and expects the status and, for errors, the problem code.
+func (c client) do(method, path string, body any, status int, code string) map[string]any {
+	c.t.Helper()
+
+	var reader io.Reader
+	switch body := body.(type) {
+	case nil:
+	case string:
+		reader = bytes.NewBufferString(body)
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	if reader != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	c.router.ServeHTTP(rec, req)
+
+	var resp map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		c.t.Fatalf("%s %s: invalid JSON response %q: %v", method, path, rec.Body.String(), err)
+	}
+	if rec.Code != status {
+		c.t.Fatalf("%s %s: status %d, want %d; body %s", method, path, rec.Code, status, rec.Body.String())
+	}
+	if code != "" && resp["code"] != code {
+		c.t.Fatalf("%s %s: problem code %v, want %s; body %s", method, path, resp["code"], code, rec.Body.String())
+	}
+	return resp
+}
+
+// get sends a GET request for a response that isn't JSON and returns its
+// body.
+func (c client) get(path string, status int) string {
+	c.t.Helper()
+
+	rec := httptest.NewRecorder()
+	c.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if rec.Code != status {
+		c.t.Fatalf("GET %s: status %d, want %d; body %s", path, rec.Code, status, rec.Body.String())
+	}
+	return rec.Body.String()
+}
+
+// field walks nested JSON objects and arrays, e.g. field(resp, "user", "id")
+// or field(resp, "data", 0, "name").
+func field(t *testing.T, v any, path ...any) any {
+	t.Helper()
+	for _, p := range path {
+		switch key := p.(type) {
+		case string:
+			obj, ok := v.(map[string]any)
+			if !ok {
+				t.Fatalf("%v is not an object", v)
+			}
+			v = obj[key]
+		case int:
+			arr, ok := v.([]any)
+			if !ok || key >= len(arr) {
+				t.Fatalf("%v has no element %d", v, key)
+			}
+			v = arr[key]
+		}
+	}
+	return v
+}
+
+func TestRoutes(t *testing.T) {
+	for name, newBackend := range map[string]func(t *testing.T) backend{
+		"memory":   memoryBackend,
+		"postgres": postgresBackend,
+	} {
+		newBackend := newBackend
+		t.Run(name, func(t *testing.T) {
+			t.Run("Health", func(t *testing.T) { testHealthRoutes(t, newBackend(t)) })
+			t.Run("Users", func(t *testing.T) { testUserRoutes(t, newBackend(t)) })
+			t.Run("Tasks", func(t *testing.T) { testTaskRoutes(t, newBackend(t)) })
+			t.Run("TimerTimestamps", func(t *testing.T) { testTimerTimestamps(t, newBackend(t)) })
+			t.Run("Tags", func(t *testing.T) { testTagRoutes(t, newBackend(t)) })
+			t.Run("Search", func(t *testing.T) { testSearchRoutes(t, newBackend(t)) })
+			t.Run("AutoStopPolicies", func(t *testing.T) { testAutoStopPolicyRoutes(t, newBackend(t)) })
+			t.Run("LogLevel", func(t *testing.T) { testLogLevelRoutes(t, newBackend(t)) })
+		})
+	}
+}
+
+func testHealthRoutes(t *testing.T, b backend) {
+	c := client{t, newRouter(b, clock.System), nil}
+
+	c.do(http.MethodGet, "/healthz", nil, http.StatusOK, "")
+	c.do(http.MethodGet, "/readyz", nil, http.StatusOK, "")
+	if resp := c.do(http.MethodGet, "/version", nil, http.StatusOK, ""); resp["schemaVersion"] == "" {
+		t.Fatalf("GET /version has no schema version: %v", resp)
+	}
+	c.do(http.MethodGet, "/no/such/route", nil, http.StatusNotFound, "route_not_found")
+
+	// Паника в обработчике превращается в 500
+	c.router.GET("/panic", func(*gin.Context) { panic("boom") })
+	c.do(http.MethodGet, "/panic", nil, http.StatusInternalServerError, "internal_error")
+
+	// Метрики учитывают и запросы, закончившиеся паникой
+	body := c.get("/metrics", http.StatusOK)
+	for _, series := range []string{
+		`timetracker_http_requests_total{method="GET",route="/healthz",status="200"} 1`,
+		`timetracker_http_requests_total{method="GET",route="/panic",status="500"} 1`,
+		`timetracker_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+	} {
+		if !strings.Contains(body, series) {
+			t.Fatalf("GET /metrics has no %s", series)
+		}
+	}
+	if body := c.get("/swagger/doc.json", http.StatusOK); !strings.Contains(body, `"/users/"`) {
+		t.Fatalf("GET /swagger/doc.json doesn't describe the API: %.200s", body)
+	}
+	c.get("/swagger/index.html", http.StatusOK)
+
+	// Ошибка проверки попадает только в лог
+	b.checks = append(b.checks, service.HealthCheck{Name: "broken", Check: func(context.Context) error {
+		return errors.New("dial tcp 10.0.0.5:5432: connection refused")
+	}})
+	c = client{t, newRouter(b, clock.System), nil}
+	resp := c.do(http.MethodGet, "/readyz", nil, http.StatusServiceUnavailable, "")
+	if check := field(t, resp, "checks", "broken"); fmt.Sprint(check) != fmt.Sprint(map[string]any{"status": "fail", "code": "check_failed"}) {
+		t.Fatalf("GET /readyz reports the broken check as %v", check)
+	}
+}
+
+func testUserRoutes(t *testing.T, b backend) {
+	c := client{t, newRouter(b, clock.System), nil}
+
+	// Создание
+	resp := c.do(http.MethodPost, "/users/", map[string]string{"passportNumber": "1234 567890"}, http.StatusOK, "")
+	if field(t, resp, "user", "surname") != "Ivanov" || field(t, resp, "user", "address") != "Moscow" {
+		t.Fatalf("POST /users/ = %v", resp)
+	}
+	id := int(field(t, resp, "user", "id").(float64))
+	c.do(http.MethodPost, "/users/", map[string]string{"passportNumber": "1111 111111"}, http.StatusOK, "")
+
+	c.do(http.MethodPost, "/users/", map[string]string{"passportNumber": "1234 567890"}, http.StatusConflict, "passport_taken")
+	c.do(http.MethodPost, "/users/", map[string]string{"passportNumber": "9999 999999"}, http.StatusBadRequest, "person_not_found")
+	c.do(http.MethodPost, "/users/", map[string]string{"passportNumber": "12"}, http.StatusBadRequest, "")
+	c.do(http.MethodPost, "/users/", "{", http.StatusBadRequest, "")
+
+	// Список
+	resp = c.do(http.MethodGet, "/users/", nil, http.StatusOK, "")
+	if resp["total"] != 2.0 || len(field(t, resp, "data").([]any)) != 2 {
+		t.Fatalf("GET /users/ = %v", resp)
+	}
+	resp = c.do(http.MethodGet, "/users/?surname=petr&sort=surname&order=desc", nil, http.StatusOK, "")
+	if resp["total"] != 1.0 || field(t, resp, "data", 0, "surname") != "Petrov" {
+		t.Fatalf("GET /users/ filtered = %v", resp)
+	}
+	resp = c.do(http.MethodGet, "/users/?q=Ivanov+Ivan", nil, http.StatusOK, "")
+	if resp["total"] != 1.0 || field(t, resp, "data", 0, "id") != float64(id) {
+		t.Fatalf("GET /users/ searched = %v", resp)
+	}
+	resp = c.do(http.MethodGet, "/users/?limit=1", nil, http.StatusOK, "")
+	cursor, _ := resp["nextCursor"].(string)
+	if cursor == "" {
+		t.Fatalf("GET /users/?limit=1 has no next cursor: %v", resp)
+	}
+	resp = c.do(http.MethodGet, "/users/?limit=1&cursor="+cursor, nil, http.StatusOK, "")
+	if field(t, resp, "data", 0, "surname") != "Petrov" {
+		t.Fatalf("GET /users/ next page = %v", resp)
+	}
+	c.do(http.MethodGet, "/users/?sort=password", nil, http.StatusBadRequest, "")
+	c.do(http.MethodGet, "/users/?limit=1&cursor=garbage", nil, http.StatusBadRequest, "invalid_cursor")
+
+	// Изменение
+	update := map[string]string{"name": "Ivan", "surname": "Ivanov", "passportNumber": "1234 567890", "address": "Tver", "team": "core"}
+	resp = c.do(http.MethodPut, fmt.Sprintf("/users/%d", id), update, http.StatusOK, "")
+	if field(t, resp, "user", "team") != "core" {
+		t.Fatalf("PUT /users/%d = %v", id, resp)
+	}
+	update["passportNumber"] = "1111 111111"
+	c.do(http.MethodPut, fmt.Sprintf("/users/%d", id), update, http.StatusConflict, "passport_taken")
+	c.do(http.MethodPut, "/users/x", update, http.StatusBadRequest, "invalid_user_id")
+
+	resp = c.do(http.MethodPatch, fmt.Sprintf("/users/%d", id), map[string]any{"address": "Kazan", "patronymic": nil}, http.StatusOK, "")
+	if field(t, resp, "user", "address") != "Kazan" || field(t, resp, "user", "patronymic") != "" || field(t, resp, "user", "team") != "core" {
+		t.Fatalf("PATCH /users/%d = %v", id, resp)
+	}
+	c.do(http.MethodPatch, fmt.Sprintf("/users/%d", id), "[]", http.StatusBadRequest, "invalid_patch")
+	c.do(http.MethodPatch, "/users/999", map[string]string{"address": "Kazan"}, http.StatusNotFound, "user_not_found")
+
+	// Удаление и восстановление
+	c.do(http.MethodDelete, fmt.Sprintf("/users/%d", id), nil, http.StatusOK, "")
+	c.do(http.MethodDelete, fmt.Sprintf("/users/%d", id), nil, http.StatusNotFound, "user_not_found")
+	resp = c.do(http.MethodGet, "/users/?includeDeleted=true", nil, http.StatusOK, "")
+	if resp["total"] != 2.0 {
+		t.Fatalf("GET /users/?includeDeleted=true = %v", resp)
+	}
+	c.do(http.MethodPut, fmt.Sprintf("/users/%d", id), update, http.StatusNotFound, "user_not_found")
+	c.do(http.MethodPut, "/users/999", update, http.StatusNotFound, "user_not_found")
+	c.do(http.MethodPost, fmt.Sprintf("/users/%d/restore", id), nil, http.StatusOK, "")
+	c.do(http.MethodPost, fmt.Sprintf("/users/%d/restore", id), nil, http.StatusNotFound, "deleted_user_not_found")
+
+	// Обезличивание только с токеном администратора
+	c.do(http.MethodPost, fmt.Sprintf("/admin/users/%d/purge", id), nil, http.StatusUnauthorized, "unauthorized")
+	admin := client{t, c.router, adminHeader()}
+	admin.do(http.MethodPost, fmt.Sprintf("/admin/users/%d/purge", id), nil, http.StatusOK, "")
+	admin.do(http.MethodPost, fmt.Sprintf("/admin/users/%d/purge", id), nil, http.StatusNotFound, "user_not_found")
+	resp = c.do(http.MethodGet, "/users/?active=false", nil, http.StatusOK, "")
+	if field(t, resp, "data", 0, "surname") != "" {
+		t.Fatalf("purged user wasn't anonymised: %v", resp)
+	}
+}
+
+func testTaskRoutes(t *testing.T, b backend) {
+	c := client{t, newRouter(b, clock.System), nil}
+	ctx := context.Background()
+
+	resp := c.do(http.MethodPost, "/users/", map[string]string{"passportNumber": "1234 567890"}, http.StatusOK, "")
+	user := int(field(t, resp, "user", "id").(float64))
+	task := b.addTask(t, "Invoices")
+	base := fmt.Sprintf("/tasks/%d", user)
+
+	c.do(http.MethodPost, fmt.Sprintf("%s/tasks/%d/start", base, task+100), nil, http.StatusNotFound, "task_not_found")
+	c.do(http.MethodPost, "/tasks/x/tasks/1/start", nil, http.StatusBadRequest, "invalid_user_id")
+	c.do(http.MethodPost, base+"/tasks/x/start", nil, http.StatusBadRequest, "invalid_task_id")
+
+	resp = c.do(http.MethodPost, fmt.Sprintf("%s/tasks/%d/start", base, task), nil, http.StatusOK, "")
+	if resp["status"] != "started" {
+		t.Fatalf("start = %v", resp)
+	}
+	resp = c.do(http.MethodGet, base+"/timers", nil, http.StatusOK, "")
+	if field(t, resp, "timers", 0, "taskName") != "Invoices" {
+		t.Fatalf("GET %s/timers = %v", base, resp)
+	}
+
+	resp = c.do(http.MethodPost, fmt.Sprintf("%s/tasks/%d/heartbeat", base, task), nil, http.StatusOK, "")
+	if resp["status"] != "recorded" {
+		t.Fatalf("heartbeat = %v", resp)
+	}
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	c.do(http.MethodPost, fmt.Sprintf("%s/tasks/%d/heartbeat", base, task), map[string]string{"at": future}, http.StatusBadRequest, "heartbeat_in_future")
+
+	c.do(http.MethodPost, fmt.Sprintf("%s/tasks/%d/stop", base, task), nil, http.StatusOK, "")
+	c.do(http.MethodPost, fmt.Sprintf("%s/tasks/%d/stop", base, task), nil, http.StatusNotFound, "timer_not_running")
+	c.do(http.MethodPost, fmt.Sprintf("%s/tasks/%d/heartbeat", base, task), nil, http.StatusNotFound, "timer_not_running")
+	resp = c.do(http.MethodGet, base+"/timers", nil, http.StatusOK, "")
+	if len(field(t, resp, "timers").([]any)) != 0 {
+		t.Fatalf("GET %s/timers after stop = %v", base, resp)
+	}
+
+	resp = c.do(http.MethodGet, base+"/worklogs", nil, http.StatusOK, "")
+	if field(t, resp, "worklogs", 0, "name") != "Invoices" {
+		t.Fatalf("GET %s/worklogs = %v", base, resp)
+	}
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	resp = c.do(http.MethodGet, base+"/worklogs?startDate=2000-01-01&endDate="+tomorrow, nil, http.StatusOK, "")
+	if field(t, resp, "worklogs", 0, "id") != float64(task) {
+		t.Fatalf("GET %s/worklogs in a period = %v", base, resp)
+	}
+	resp = c.do(http.MethodGet, base+"/worklogs?startDate=2000-01-01&endDate=2000-01-02", nil, http.StatusOK, "")
+	if worklogs, _ := resp["worklogs"].([]any); len(worklogs) != 0 {
+		t.Fatalf("GET %s/worklogs in an empty period = %v", base, resp)
+	}
+	c.do(http.MethodGet, base+"/worklogs?startDate=2000-01-02&endDate=2000-01-01", nil, http.StatusBadRequest, "")
+	c.do(http.MethodGet, base+"/worklogs?startDate=yesterday", nil, http.StatusBadRequest, "")
+
+	// Простой находит фоновая проверка, здесь её заменяет вызов репозитория
+	c.do(http.MethodPost, fmt.Sprintf("%s/tasks/%d/start", base, task), nil, http.StatusOK, "")
+	flagged, err := b.tasks.DetectIdle(ctx, time.Now().Add(time.Minute), false)
+	if err != nil || len(flagged) != 1 {
+		t.Fatalf("DetectIdle = %v, %v", flagged, err)
+	}
+	idle := fmt.Sprintf("%s/worklogs/%d/idle", base, flagged[0].ID)
+
+	c.do(http.MethodPost, idle, map[string]string{"action": "maybe"}, http.StatusBadRequest, "")
+	resp = c.do(http.MethodPost, idle, map[string]string{"action": "discard"}, http.StatusOK, "")
+	if resp["status"] != "discarded" {
+		t.Fatalf("POST %s = %v", idle, resp)
+	}
+	c.do(http.MethodPost, idle, map[string]string{"action": "accept"}, http.StatusConflict, "no_idle_proposal")
+	c.do(http.MethodPost, fmt.Sprintf("%s/worklogs/%d/idle", base, flagged[0].ID+100), map[string]string{"action": "accept"}, http.StatusNotFound, "worklog_not_found")
+}
+
+// testTimerTimestamps checks that start and stop report the times that were
+// stored, which the worklogs then add up.
+func testTimerTimestamps(t *testing.T, b backend) {
+	now := clock.NewFake(time.Date(2024, 3, 4, 9, 0, 0, 123456789, time.UTC))
+	c := client{t, newRouter(b, now), nil}
+
+	resp := c.do(http.MethodPost, "/users/", map[string]string{"passportNumber": "1234 567890"}, http.StatusOK, "")
+	base := fmt.Sprintf("/tasks/%d", int(field(t, resp, "user", "id").(float64)))
+	task := b.addTask(t, "Invoices")
+
+	resp = c.do(http.MethodPost, fmt.Sprintf("%s/tasks/%d/start", base, task), nil, http.StatusOK, "")
+	started := parseTime(t, resp["timestamp"])
+	if want := time.Date(2024, 3, 4, 9, 0, 0, 123456000, time.UTC); !started.Equal(want) {
+		t.Fatalf("start timestamp = %v, want %v", started, want)
+	}
+	resp = c.do(http.MethodGet, base+"/timers", nil, http.StatusOK, "")
+	if stored := parseTime(t, field(t, resp, "timers", 0, "startTime")); !stored.Equal(started) {
+		t.Fatalf("stored start time = %v, want %v", stored, started)
+	}
+
+	now.Advance(90*time.Minute + 30*time.Second)
+	resp = c.do(http.MethodPost, fmt.Sprintf("%s/tasks/%d/stop", base, task), nil, http.StatusOK, "")
+	if stopped := parseTime(t, resp["timestamp"]); stopped.Sub(started) != 90*time.Minute+30*time.Second {
+		t.Fatalf("stop timestamp = %v, started %v", stopped, started)
+	}
+
+	resp = c.do(http.MethodGet, base+"/worklogs", nil, http.StatusOK, "")
+	if field(t, resp, "worklogs", 0, "total_hours") != 1.0 || field(t, resp, "worklogs", 0, "total_minutes") != 30.0 {
+		t.Fatalf("GET %s/worklogs = %v", base, resp)
+	}
+}
+
+func testTagRoutes(t *testing.T, b backend) {
+	now := clock.NewFake(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC))
+	c := client{t, newRouter(b, now), nil}
+
+	resp := c.do(http.MethodPost, "/users/", map[string]string{"passportNumber": "1234 567890"}, http.StatusOK, "")
+	user := int(field(t, resp, "user", "id").(float64))
+	resp = c.do(http.MethodPost, "/users/", map[string]string{"passportNumber": "1111 111111"}, http.StatusOK, "")
+	other := int(field(t, resp, "user", "id").(float64))
+	invoices := b.addTask(t, "Invoices")
+	standup := b.addTask(t, "Standup")
+	tags := fmt.Sprintf("/users/%d/tags/", user)
+	base := fmt.Sprintf("/tasks/%d", user)
+
+	// Теги
+	resp = c.do(http.MethodPost, tags, map[string]string{"name": "billing", "color": "#1E88E5"}, http.StatusOK, "")
+	if field(t, resp, "tag", "color") != "#1e88e5" {
+		t.Fatalf("POST %s = %v", tags, resp)
+	}
+	billing := int(field(t, resp, "tag", "id").(float64))
+	resp = c.do(http.MethodPost, tags, map[string]string{"name": "meeting"}, http.StatusOK, "")
+	if field(t, resp, "tag", "color") != service.DefaultTagColor {
+		t.Fatalf("POST %s without a color = %v", tags, resp)
+	}
+	meeting := int(field(t, resp, "tag", "id").(float64))
+	resp = c.do(http.MethodPost, fmt.Sprintf("/users/%d/tags/", other), map[string]string{"name": "billing"}, http.StatusOK, "")
+	foreign := int(field(t, resp, "tag", "id").(float64))
+
+	c.do(http.MethodPost, tags, map[string]string{"name": "billing"}, http.StatusConflict, "tag_name_taken")
+	c.do(http.MethodPost, tags, map[string]string{"name": "red", "color": "red"}, http.StatusBadRequest, "")
+	c.do(http.MethodPost, "/users/999/tags/", map[string]string{"name": "billing"}, http.StatusNotFound, "user_not_found")
+	c.do(http.MethodPut, fmt.Sprintf("%s%d", tags, meeting), map[string]string{"name": "billing"}, http.StatusConflict, "tag_name_taken")
+	c.do(http.MethodPut, fmt.Sprintf("%s%d", tags, foreign), map[string]string{"name": "sales"}, http.StatusNotFound, "tag_not_found")
+	c.do(http.MethodPut, tags+"x", map[string]string{"name": "sales"}, http.StatusBadRequest, "invalid_tag_id")
+
+	resp = c.do(http.MethodPut, fmt.Sprintf("%s%d", tags, meeting), map[string]string{"name": "meetings", "color": "#43a047"}, http.StatusOK, "")
+	if field(t, resp, "tag", "name") != "meetings" {
+		t.Fatalf("PUT %s%d = %v", tags, meeting, resp)
+	}
+	resp = c.do(http.MethodGet, tags, nil, http.StatusOK, "")
+	if len(field(t, resp, "tags").([]any)) != 2 || field(t, resp, "tags", 1, "color") != "#43a047" {
+		t.Fatalf("GET %s = %v", tags, resp)
+	}
+
+	// Заметки и теги таймера
+	start := fmt.Sprintf("%s/tasks/%d/start", base, invoices)
+	c.do(http.MethodPost, start, map[string]any{"tagIds": []int{foreign}}, http.StatusNotFound, "tag_not_found")
+	c.do(http.MethodPost, start, map[string]any{"note": "March invoices", "tagIds": []int{billing}}, http.StatusOK, "")
+	resp = c.do(http.MethodGet, base+"/timers", nil, http.StatusOK, "")
+	if field(t, resp, "timers", 0, "note") != "March invoices" || field(t, resp, "timers", 0, "tags", 0, "name") != "billing" {
+		t.Fatalf("GET %s/timers = %v", base, resp)
+	}
+	worklog := int(field(t, resp, "timers", 0, "id").(float64))
+
+	now.Advance(2 * time.Hour)
+	c.do(http.MethodPost, fmt.Sprintf("%s/tasks/%d/stop", base, invoices), map[string]string{"note": "sent to the client"}, http.StatusOK, "")
+
+	c.do(http.MethodPost, fmt.Sprintf("%s/tasks/%d/start", base, standup), nil, http.StatusOK, "")
+	now.Advance(30 * time.Minute)
+	c.do(http.MethodPost, fmt.Sprintf("%s/tasks/%d/stop", base, standup), nil, http.StatusOK, "")
+
+	resp = c.do(http.MethodPut, fmt.Sprintf("%s/tasks/%d/tags", base, standup), map[string]any{"tagIds": []int{meeting}}, http.StatusOK, "")
+	if field(t, resp, "tags", 0, "name") != "meetings" {
+		t.Fatalf("PUT task tags = %v", resp)
+	}
+	c.do(http.MethodPut, fmt.Sprintf("%s/tasks/%d/tags", base, standup+100), map[string]any{"tagIds": []int{meeting}}, http.StatusNotFound, "task_not_found")
+	c.do(http.MethodPut, fmt.Sprintf("%s/worklogs/%d/tags", base, worklog), map[string]any{"tagIds": []int{foreign}}, http.StatusNotFound, "tag_not_found")
+	c.do(http.MethodPut, fmt.Sprintf("/tasks/%d/worklogs/%d/tags", other, worklog), map[string]any{"tagIds": []int{foreign}}, http.StatusNotFound, "worklog_not_found")
+	c.do(http.MethodPut, fmt.Sprintf("%s/worklogs/%d/tags", base, worklog), "{}", http.StatusBadRequest, "")
+
+	// Отчёты
+	resp = c.do(http.MethodGet, base+"/worklogs?tag=billing", nil, http.StatusOK, "")
+	if len(field(t, resp, "worklogs").([]any)) != 1 || field(t, resp, "worklogs", 0, "name") != "Invoices" {
+		t.Fatalf("GET %s/worklogs?tag=billing = %v", base, resp)
+	}
+	resp = c.do(http.MethodGet, base+"/worklogs?groupBy=tag", nil, http.StatusOK, "")
+	if field(t, resp, "tags", 0, "tag", "name") != "billing" || field(t, resp, "tags", 0, "total_hours") != 2.0 ||
+		field(t, resp, "tags", 1, "tag", "name") != "meetings" || field(t, resp, "tags", 1, "total_minutes") != 30.0 {
+		t.Fatalf("GET %s/worklogs?groupBy=tag = %v", base, resp)
+	}
+	c.do(http.MethodGet, base+"/worklogs?groupBy=user", nil, http.StatusBadRequest, "")
+
+	// После удаления тега время Invoices остаётся без тегов
+	c.do(http.MethodDelete, fmt.Sprintf("%s%d", tags, billing), nil, http.StatusOK, "")
+	c.do(http.MethodDelete, fmt.Sprintf("%s%d", tags, billing), nil, http.StatusNotFound, "tag_not_found")
+	resp = c.do(http.MethodGet, base+"/worklogs?groupBy=tag", nil, http.StatusOK, "")
+	if field(t, resp, "tags", 0, "tag") != nil || field(t, resp, "tags", 0, "total_hours") != 2.0 {
+		t.Fatalf("GET %s/worklogs?groupBy=tag after delete = %v", base, resp)
+	}
+}
+
+func testSearchRoutes(t *testing.T, b backend) {
+	// Полдень UTC попадает в 4 марта в любом часовом поясе сервера
+	now := clock.NewFake(time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC))
+	c := client{t, newRouter(b, now), nil}
+
+	resp := c.do(http.MethodPost, "/users/", map[string]string{"passportNumber": "1234 567890"}, http.StatusOK, "")
+	user := int(field(t, resp, "user", "id").(float64))
+	invoices := b.addTask(t, "Invoices")
+	standup := b.addTask(t, "Standup")
+	base := fmt.Sprintf("/tasks/%d", user)
+
+	c.do(http.MethodPost, fmt.Sprintf("%s/tasks/%d/start", base, standup), map[string]string{"note": "talked over invoices"}, http.StatusOK, "")
+	now.Advance(15 * time.Minute)
+	c.do(http.MethodPost, fmt.Sprintf("%s/tasks/%d/stop", base, standup), nil, http.StatusOK, "")
+
+	resp = c.do(http.MethodPost, "/users/", map[string]string{"passportNumber": "1111 111111"}, http.StatusOK, "")
+	other := int(field(t, resp, "user", "id").(float64))
+	c.do(http.MethodPost, fmt.Sprintf("/tasks/%d/tasks/%d/start", other, standup), map[string]string{"note": "private invoices"}, http.StatusOK, "")
+
+	// Без пользователя ищет только администратор
+	c.do(http.MethodGet, "/search?q=invoices", nil, http.StatusBadRequest, "validation_failed")
+	c.do(http.MethodGet, "/search?q=invoices&all=true", nil, http.StatusUnauthorized, "unauthorized")
+	c.do(http.MethodGet, fmt.Sprintf("/search?q=invoices&all=true&userId=%d", user), nil, http.StatusBadRequest, "validation_failed")
+
+	resp = c.do(http.MethodGet, fmt.Sprintf("/search?q=invoices&userId=%d", user), nil, http.StatusOK, "")
+	if hits := field(t, resp, "hits").([]any); len(hits) != 1 || field(t, resp, "hits", 0, "userId") != float64(user) ||
+		field(t, resp, "hits", 0, "taskName") != "Standup" {
+		t.Fatalf("GET /search?userId= = %v", resp)
+	}
+	if snippet, _ := field(t, resp, "hits", 0, "snippet").(string); !strings.Contains(snippet, "<b>invoices</b>") {
+		t.Fatalf("GET /search?userId= snippet = %q", snippet)
+	}
+
+	admin := client{t, c.router, adminHeader()}
+	resp = admin.do(http.MethodGet, "/search?q=invoices&all=true", nil, http.StatusOK, "")
+	if hits := field(t, resp, "hits").([]any); len(hits) != 3 ||
+		field(t, resp, "hits", 0, "type") != "task" || field(t, resp, "hits", 0, "taskId") != float64(invoices) ||
+		field(t, resp, "hits", 1, "type") != "worklog" || field(t, resp, "hits", 2, "type") != "worklog" {
+		t.Fatalf("GET /search?all=true = %v", resp)
+	}
+
+	// Задача без записей в периоде не находится
+	resp = c.do(http.MethodGet, fmt.Sprintf("/search?q=invoices&userId=%d&startDate=2024-03-04&endDate=2024-03-05", user), nil, http.StatusOK, "")
+	if hits := field(t, resp, "hits").([]any); len(hits) != 1 || field(t, resp, "hits", 0, "userId") != float64(user) {
+		t.Fatalf("GET /search in a period = %v", resp)
+	}
+	resp = admin.do(http.MethodGet, "/search?q=invoices&all=true&startDate=2024-03-05", nil, http.StatusOK, "")
+	if hits := field(t, resp, "hits").([]any); len(hits) != 0 {
+		t.Fatalf("GET /search in an empty period = %v", resp)
+	}
+	resp = admin.do(http.MethodGet, "/search?q=invoices&all=true&limit=1", nil, http.StatusOK, "")
+	if hits := field(t, resp, "hits").([]any); len(hits) != 1 {
+		t.Fatalf("GET /search?limit=1 = %v", resp)
+	}
+
+	c.do(http.MethodGet, "/search", nil, http.StatusBadRequest, "")
+	c.do(http.MethodGet, fmt.Sprintf("/search?q=invoices&userId=%d&limit=1000", user), nil, http.StatusBadRequest, "")
+	c.do(http.MethodGet, fmt.Sprintf("/search?q=invoices&userId=%d&startDate=2024-03-05&endDate=2024-03-04", user), nil, http.StatusBadRequest, "")
+}
+
+func parseTime(t *testing.T, v any) time.Time {
+	t.Helper()
+	s, _ := v.(string)
+	parsed, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		t.Fatalf("invalid timestamp %v: %v", v, err)
+	}
+	return parsed
+}
+
+func testAutoStopPolicyRoutes(t *testing.T, b backend) {
+	c := client{t, newRouter(b, clock.System), nil}
+
+	resp := c.do(http.MethodPost, "/users/", map[string]string{"passportNumber": "1234 567890"}, http.StatusOK, "")
+	user := int(field(t, resp, "user", "id").(float64))
+	policy := map[string]string{"maxDuration": "8h", "stopAt": "cap", "cap": "1h"}
+
+	// Политики меняет только администратор
+	c.do(http.MethodGet, "/admin/auto-stop-policies/", nil, http.StatusUnauthorized, "unauthorized")
+	c.do(http.MethodPut, "/admin/auto-stop-policies/teams/core", policy, http.StatusUnauthorized, "unauthorized")
+	c.header = adminHeader()
+
+	resp = c.do(http.MethodGet, "/admin/auto-stop-policies/", nil, http.StatusOK, "")
+	if data, _ := resp["data"].([]any); len(data) != 0 {
+		t.Fatalf("GET /admin/auto-stop-policies/ = %v", resp)
+	}
+
+	resp = c.do(http.MethodPut, fmt.Sprintf("/admin/auto-stop-policies/users/%d", user), policy, http.StatusOK, "")
+	if field(t, resp, "policy", "maxDuration") != "8h0m0s" {
+		t.Fatalf("PUT user policy = %v", resp)
+	}
+	c.do(http.MethodPut, "/admin/auto-stop-policies/users/999", policy, http.StatusNotFound, "user_not_found")
+	c.do(http.MethodPut, "/admin/auto-stop-policies/teams/core", policy, http.StatusOK, "")
+	c.do(http.MethodPut, "/admin/auto-stop-policies/teams/core", map[string]string{"maxDuration": "8h", "stopAt": "never", "cap": "1h"}, http.StatusBadRequest, "")
+	c.do(http.MethodPut, "/admin/auto-stop-policies/teams/"+strings.Repeat("к", 101), policy, http.StatusBadRequest, "invalid_team")
+	c.do(http.MethodDelete, "/admin/auto-stop-policies/teams/"+strings.Repeat("к", 101), nil, http.StatusBadRequest, "invalid_team")
+	c.do(http.MethodPut, "/admin/auto-stop-policies/teams/"+strings.Repeat("к", 100), policy, http.StatusOK, "")
+
+	resp = c.do(http.MethodGet, "/admin/auto-stop-policies/", nil, http.StatusOK, "")
+	if len(field(t, resp, "data").([]any)) != 3 {
+		t.Fatalf("GET /admin/auto-stop-policies/ = %v", resp)
+	}
+
+	c.do(http.MethodDelete, fmt.Sprintf("/admin/auto-stop-policies/users/%d", user), nil, http.StatusOK, "")
+	c.do(http.MethodDelete, fmt.Sprintf("/admin/auto-stop-policies/users/%d", user), nil, http.StatusNotFound, "policy_not_found")
+	c.do(http.MethodDelete, "/admin/auto-stop-policies/teams/core", nil, http.StatusOK, "")
+	c.do(http.MethodDelete, "/admin/auto-stop-policies/teams/core", nil, http.StatusNotFound, "policy_not_found")
+}
+
+func testLogLevelRoutes(t *testing.T, b backend) {
+	defer logger.SetLevel(logger.GetLevel())
+
+	// Без токена маршруты закрыты
+	c := client{t, newRouter(b, clock.System), nil}
+	c.do(http.MethodGet, "/admin/log-level", nil, http.StatusUnauthorized, "unauthorized")
+	c.header = http.Header{"Authorization": {"Bearer wrong"}}
+	c.do(http.MethodPut, "/admin/log-level", map[string]string{"level": "debug"}, http.StatusUnauthorized, "unauthorized")
+
+	c.header = adminHeader()
+	c.do(http.MethodPut, "/admin/log-level", map[string]string{"level": "debug"}, http.StatusOK, "")
+	if resp := c.do(http.MethodGet, "/admin/log-level", nil, http.StatusOK, ""); resp["level"] != "debug" {
+		t.Fatalf("GET /admin/log-level = %v", resp)
+	}
+	c.do(http.MethodPut, "/admin/log-level", map[string]string{"level": "verbose"}, http.StatusBadRequest, "")
+	c.do(http.MethodPut, "/admin/log-level", map[string]string{"level": "off"}, http.StatusBadRequest, "")
+
+	// Без ADMIN_TOKEN маршруты есть, но закрыты для всех
+	client{t, newAppRouter(b, clock.System, &config.Config{}), c.header}.do(http.MethodGet, "/admin/log-level", nil, http.StatusUnauthorized, "unauthorized")
+}
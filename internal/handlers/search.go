@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/KarmaBeLike/time-tracker-api/config"
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+	"github.com/KarmaBeLike/time-tracker-api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type SearchHandler struct {
+	searchService service.SearchService
+	admin         *AdminToken
+}
+
+func NewSearchHandler(searchService service.SearchService, admin *AdminToken) *SearchHandler {
+	return &SearchHandler{searchService: searchService, admin: admin}
+}
+
+func (h *SearchHandler) Routes(router *gin.Engine, cfg *config.Config) {
+	router.GET("/search", h.Search) // @summary Search tasks and worklog notes
+}
+
+// @Summary Search tasks and worklog notes
+// @Description Full-text search over task names and descriptions and worklog notes, best matches first. The query takes words, "quoted phrases", or and -word. Snippets are HTML with the matches in <b> tags. The user and the period narrow down the worklogs, and the tasks to those with such worklogs. A user is required; searching every user's worklogs with all=true needs the admin token.
+// @Tags Search
+// @Produce json
+// @Param q query string true "Search query"
+// @Param userId query int false "Only worklogs of this user; required unless all is set"
+// @Param all query bool false "Search the worklogs of every user; needs the admin token"
+// @Param startDate query string false "Only worklogs started on or after this date (YYYY-MM-DD)"
+// @Param endDate query string false "Only worklogs started before this date (YYYY-MM-DD)"
+// @Param limit query int false "Maximum number of hits" default(20)
+// @Success 200 {object} object{query=string,hits=[]models.SearchHit}
+// @Security AdminToken
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Router /search [get]
+func (h *SearchHandler) Search(c *gin.Context) {
+	var query SearchQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+	// Поиск по всем пользователям открыт только администратору
+	if query.All && !h.admin.Authorized(c) {
+		abortUnauthorized(c)
+		return
+	}
+
+	hits, err := h.searchService.Search(c.Request.Context(), models.SearchParams{
+		Query:  query.Query,
+		UserID: query.UserID,
+		From:   optionalTime(query.StartDate),
+		To:     optionalTime(query.EndDate),
+		Limit:  query.Limit,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if hits == nil {
+		hits = []models.SearchHit{}
+	}
+
+	requestLogger(c).PrintInfo("Search completed", map[string]any{"userId": query.UserID, "all": query.All, "hits": len(hits)})
+
+	c.JSON(http.StatusOK, gin.H{"query": query.Query, "hits": hits})
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/KarmaBeLike/time-tracker-api/config"
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+	"github.com/KarmaBeLike/time-tracker-api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type TagHandler struct {
+	tagService service.TagService
+}
+
+func NewTagHandler(tagService service.TagService) *TagHandler {
+	return &TagHandler{tagService: tagService}
+}
+
+func (h *TagHandler) Routes(router *gin.Engine, cfg *config.Config) {
+	tags := router.Group("/users/:userId/tags")
+	{
+		tags.GET("/", h.GetTags)            // @summary Get the tags of a user
+		tags.POST("/", h.CreateTag)         // @summary Create a tag
+		tags.PUT("/:tagId", h.UpdateTag)    // @summary Rename or recolor a tag
+		tags.DELETE("/:tagId", h.DeleteTag) // @summary Delete a tag
+	}
+
+	tagged := router.Group("/tasks/:userId")
+	{
+		tagged.PUT("/worklogs/:worklogId/tags", h.SetWorklogTags) // @summary Set the tags of a worklog
+		tagged.PUT("/tasks/:taskId/tags", h.SetTaskTags)          // @summary Set the tags of a task
+	}
+}
+
+// @Summary Get the tags of a user
+// @Tags Tags
+// @Produce json
+// @Param userId path int true "User ID"
+// @Success 200 {object} object{userId=int,tags=[]models.Tag}
+// @Router /users/{userId}/tags [get]
+func (h *TagHandler) GetTags(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	tags, err := h.tagService.GetTags(c.Request.Context(), userId)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if tags == nil {
+		tags = []models.Tag{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"userId": userId, "tags": tags})
+}
+
+// @Summary Create a tag
+// @Description Tag names are unique per user. The color defaults to grey.
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Param userId path int true "User ID"
+// @Param tag body TagRequest true "Tag"
+// @Success 200 {object} object{status=string,tag=models.Tag}
+// @Failure 400 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 409 {object} Problem
+// @Router /users/{userId}/tags [post]
+func (h *TagHandler) CreateTag(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	var req TagRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	tag, err := h.tagService.CreateTag(c.Request.Context(), userId, req.toModel())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "created", "tag": tag})
+}
+
+// @Summary Rename or recolor a tag
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Param userId path int true "User ID"
+// @Param tagId path int true "Tag ID"
+// @Param tag body TagRequest true "Tag"
+// @Success 200 {object} object{status=string,tag=models.Tag}
+// @Failure 400 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 409 {object} Problem
+// @Router /users/{userId}/tags/{tagId} [put]
+func (h *TagHandler) UpdateTag(c *gin.Context) {
+	userId, tagId, ok := tagParams(c)
+	if !ok {
+		return
+	}
+
+	var req TagRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	tag, err := h.tagService.UpdateTag(c.Request.Context(), userId, tagId, req.toModel())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "updated", "tag": tag})
+}
+
+// @Summary Delete a tag
+// @Description The tag is removed from all worklogs and tasks.
+// @Tags Tags
+// @Param userId path int true "User ID"
+// @Param tagId path int true "Tag ID"
+// @Success 200
+// @Failure 404 {object} Problem
+// @Router /users/{userId}/tags/{tagId} [delete]
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+	userId, tagId, ok := tagParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.tagService.DeleteTag(c.Request.Context(), userId, tagId); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "deleted", "tagId": tagId})
+}
+
+// @Summary Set the tags of a worklog
+// @Description Replace the tags of a worklog with tags of the user.
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Param userId path int true "User ID"
+// @Param worklogId path int true "Worklog ID"
+// @Param tags body SetTagsRequest true "Tags"
+// @Success 200 {object} object{worklogId=int,tags=[]models.Tag}
+// @Failure 404 {object} Problem
+// @Router /tasks/{userId}/worklogs/{worklogId}/tags [put]
+func (h *TagHandler) SetWorklogTags(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	worklogId, err := strconv.Atoi(c.Param("worklogId"))
+	if err != nil {
+		c.Error(errInvalidWorklogID)
+		return
+	}
+
+	var req SetTagsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	tags, err := h.tagService.SetWorklogTags(c.Request.Context(), userId, worklogId, req.TagIDs)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if tags == nil {
+		tags = []models.Tag{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"worklogId": worklogId, "tags": tags})
+}
+
+// @Summary Set the tags of a task
+// @Description Replace the tags the user has put on a task. They apply to all worklogs of the user on the task; other users tag the task on their own.
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Param userId path int true "User ID"
+// @Param taskId path int true "Task ID"
+// @Param tags body SetTagsRequest true "Tags"
+// @Success 200 {object} object{taskId=int,tags=[]models.Tag}
+// @Failure 404 {object} Problem
+// @Router /tasks/{userId}/tasks/{taskId}/tags [put]
+func (h *TagHandler) SetTaskTags(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	taskId, err := strconv.Atoi(c.Param("taskId"))
+	if err != nil {
+		c.Error(errInvalidTaskID)
+		return
+	}
+
+	var req SetTagsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	tags, err := h.tagService.SetTaskTags(c.Request.Context(), userId, taskId, req.TagIDs)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if tags == nil {
+		tags = []models.Tag{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"taskId": taskId, "tags": tags})
+}
+
+// tagParams parses the user and tag IDs of a tag route.
+func tagParams(c *gin.Context) (userId, tagId int, ok bool) {
+	userId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return 0, 0, false
+	}
+
+	tagId, err = strconv.Atoi(c.Param("tagId"))
+	if err != nil {
+		c.Error(errInvalidTagID)
+		return 0, 0, false
+	}
+	return userId, tagId, true
+}
@@ -1,14 +1,13 @@
 package handlers
 
 import (
-	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/KarmaBeLike/time-tracker-api/config"
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
 	"github.com/KarmaBeLike/time-tracker-api/internal/service"
-	"github.com/KarmaBeLike/time-tracker-api/pkg/logger"
 	"github.com/gin-gonic/gin"
 )
 
@@ -23,9 +22,12 @@ func NewTaskHandler(taskService service.TaskService) *TaskHandler {
 func (t *TaskHandler) Routes(router *gin.Engine, cfg *config.Config) {
 	task := router.Group("/tasks")
 	{
-		task.GET("/:userId/worklogs", t.GetWorklogs)           // @summary Get list of tasks for a user
-		task.POST("/:userId/tasks/:taskId/start", t.StartTask) // @summary Start a task for a user
-		task.POST("/:userId/tasks/:taskId/stop", t.StopTask)   // @summary End a task for a user
+		task.GET("/:userId/worklogs", t.GetWorklogs)                  // @summary Get list of tasks for a user
+		task.GET("/:userId/timers", t.GetRunningTimers)               // @summary Get the running timers of a user
+		task.POST("/:userId/tasks/:taskId/start", t.StartTask)        // @summary Start a task for a user
+		task.POST("/:userId/tasks/:taskId/stop", t.StopTask)          // @summary End a task for a user
+		task.POST("/:userId/tasks/:taskId/heartbeat", t.Heartbeat)    // @summary Report activity on a running task
+		task.POST("/:userId/worklogs/:worklogId/idle", t.ResolveIdle) // @summary Accept or discard idle time
 	}
 }
 
@@ -34,95 +36,235 @@ func (t *TaskHandler) Routes(router *gin.Engine, cfg *config.Config) {
 // @Tags Tasks
 // @Produce  json
 // @Param userId path int true "User ID"
-// @Param startDate query string false "Start date"
-// @Param endDate query string false "End date"
-// @Success 200 {array} Task
-// @Router /users/{userId}/tasks [get]
+// @Param startDate query string false "Start date (YYYY-MM-DD)"
+// @Param endDate query string false "End date (YYYY-MM-DD), not before startDate"
+// @Param tag query []string false "Count only worklogs with one of these tags" collectionFormat(multi)
+// @Param groupBy query string false "Add up by task (default) or by tag" Enums(task, tag)
+// @Success 200 {object} object{userId=int,startDate=string,endDate=string,worklogs=[]models.Task,tags=[]models.TagTotal} "worklogs, or tags with groupBy=tag"
+// @Router /tasks/{userId}/worklogs [get]
 func (h *TaskHandler) GetWorklogs(c *gin.Context) {
 	userIdStr := c.Param("userId")
-	startDate := c.Query("startDate")
-	endDate := c.Query("endDate")
-
 	userId, err := strconv.Atoi(userIdStr)
 	if err != nil {
-		logger.PrintError(errors.New("invalid user ID"), map[string]any{"userId": userIdStr})
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(errInvalidUserID)
 		return
 	}
 
-	worklogs, err := h.taskService.GetWorklogs(userId, startDate, endDate)
-	if err != nil {
-		logger.PrintError(errors.New("failed to get worklogs"), map[string]any{"userId": userId, "startDate": startDate, "endDate": endDate, "error": err.Error()})
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get worklogs"})
+	var query GetWorklogsQuery
+	if !bindQuery(c, &query) {
 		return
 	}
+	startDate := formatDate(query.StartDate)
+	endDate := formatDate(query.EndDate)
 
-	logger.PrintInfo("Worklogs retrieved successfully", map[string]any{"userId": userId, "startDate": startDate, "endDate": endDate})
-
-	c.JSON(http.StatusOK, gin.H{
+	// Отчёт по тегам отдаётся в поле tags вместо worklogs
+	resp := gin.H{
 		"userId":    userId,
 		"startDate": startDate,
 		"endDate":   endDate,
-		"worklogs":  worklogs,
-	})
+	}
+	if query.GroupBy == "tag" {
+		totals, err := h.taskService.GetTagTotals(c.Request.Context(), userId, startDate, endDate, query.Tags)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		resp["tags"] = totals
+	} else {
+		worklogs, err := h.taskService.GetWorklogs(c.Request.Context(), userId, startDate, endDate, query.Tags)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		resp["worklogs"] = worklogs
+	}
+
+	requestLogger(c).PrintInfo("Worklogs retrieved successfully", map[string]any{"userId": userId, "startDate": startDate, "endDate": endDate, "tags": query.Tags, "groupBy": query.GroupBy})
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Get the running timers of a user
+// @Description Get the timers a user hasn't stopped yet, with the names of their tasks
+// @Tags Tasks
+// @Produce json
+// @Param userId path int true "User ID"
+// @Success 200 {object} object{userId=int,timers=[]models.Worklog}
+// @Router /tasks/{userId}/timers [get]
+func (t *TaskHandler) GetRunningTimers(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	timers, err := t.taskService.GetRunningTimers(c.Request.Context(), userId)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if timers == nil {
+		timers = []models.Worklog{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"userId": userId, "timers": timers})
 }
 
 // @Summary Start a task for a user
-// @Description Start a task for a user based on user ID
+// @Description Start a task for a user based on user ID, optionally with a note and tags of the user
 // @Tags Tasks
+// @Accept  json
 // @Produce  json
 // @Param userId path int true "User ID"
-// @Param task body StartTaskRequest true "Task info"
-// @Success 200 {object} Task
-// @Router /users/{userId}/tasks/start [post]
+// @Param taskId path int true "Task ID"
+// @Param task body StartTaskRequest false "Task info"
+// @Success 200 {object} object{status=string,timestamp=string}
+// @Router /tasks/{userId}/tasks/{taskId}/start [post]
 func (t *TaskHandler) StartTask(c *gin.Context) {
 	userId, err := strconv.Atoi(c.Param("userId"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(errInvalidUserID)
 		return
 	}
 
 	taskId, err := strconv.Atoi(c.Param("taskId"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		c.Error(errInvalidTaskID)
+		return
+	}
+
+	var req StartTaskRequest
+	if c.Request.ContentLength != 0 && !bindJSON(c, &req) {
 		return
 	}
 
-	err = t.taskService.StartTask(userId, taskId)
+	timestamp, err := t.taskService.StartTask(c.Request.Context(), userId, taskId, req.Note, req.TagIDs)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "started", "timestamp": time.Now().UTC().Format(time.RFC3339)})
+	c.JSON(http.StatusOK, gin.H{"status": "started", "timestamp": timestamp.UTC().Format(time.RFC3339Nano)})
 }
 
 // @Summary End a task for a user
-// @Description End a task for a user based on user ID and task ID
+// @Description End a task for a user based on user ID and task ID. A note is added to the one given at the start.
 // @Tags Tasks
+// @Accept json
 // @Produce json
 // @Param userId path int true "User ID"
 // @Param taskId path int true "Task ID"
-// @Success 200 {object} Task
+// @Param task body StopTaskRequest false "Note"
+// @Success 200 {object} object{status=string,timestamp=string}
 // @Router /tasks/{userId}/tasks/{taskId}/stop [post]
 func (t *TaskHandler) StopTask(c *gin.Context) {
 	userId, err := strconv.Atoi(c.Param("userId"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	taskId, err := strconv.Atoi(c.Param("taskId"))
+	if err != nil {
+		c.Error(errInvalidTaskID)
+		return
+	}
+
+	var req StopTaskRequest
+	if c.Request.ContentLength != 0 && !bindJSON(c, &req) {
+		return
+	}
+
+	timestamp, err := t.taskService.StopTask(c.Request.Context(), userId, taskId, req.Note)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "stopped", "timestamp": timestamp.UTC().Format(time.RFC3339Nano)})
+}
+
+// formatDate formats an optional date query parameter back to YYYY-MM-DD.
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+// @Summary Report activity on a running task
+// @Description Desktop clients send heartbeats while the user is active. When heartbeats stop for longer than the idle threshold, the idle tail of the worklog is proposed for trimming (or trimmed automatically, depending on server settings).
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param userId path int true "User ID"
+// @Param taskId path int true "Task ID"
+// @Param heartbeat body HeartbeatRequest false "Time of the last user input"
+// @Success 200 {object} object{status=string,worklog=models.Worklog}
+// @Failure 404 {object} Problem
+// @Router /tasks/{userId}/tasks/{taskId}/heartbeat [post]
+func (t *TaskHandler) Heartbeat(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.Error(errInvalidUserID)
 		return
 	}
 
 	taskId, err := strconv.Atoi(c.Param("taskId"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		c.Error(errInvalidTaskID)
+		return
+	}
+
+	var req HeartbeatRequest
+	if c.Request.ContentLength != 0 && !bindJSON(c, &req) {
+		return
+	}
+
+	worklog, err := t.taskService.Heartbeat(c.Request.Context(), userId, taskId, req.At)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "recorded", "worklog": worklog})
+}
+
+// @Summary Accept or discard idle time
+// @Description Resolve the pending idle proposal of a worklog. Accepting ends the worklog where the idle time started; discarding keeps the time.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param userId path int true "User ID"
+// @Param worklogId path int true "Worklog ID"
+// @Param resolution body ResolveIdleRequest true "Resolution"
+// @Success 200 {object} object{status=string,worklog=models.Worklog}
+// @Failure 404 {object} Problem
+// @Failure 409 {object} Problem
+// @Router /tasks/{userId}/worklogs/{worklogId}/idle [post]
+func (t *TaskHandler) ResolveIdle(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	worklogId, err := strconv.Atoi(c.Param("worklogId"))
+	if err != nil {
+		c.Error(errInvalidWorklogID)
+		return
+	}
+
+	var req ResolveIdleRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	err = t.taskService.StopTask(userId, taskId)
+	worklog, err := t.taskService.ResolveIdle(c.Request.Context(), userId, worklogId, req.Action == "accept", req.Resume)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "stopped", "timestamp": time.Now().UTC().Format(time.RFC3339)})
+	c.JSON(http.StatusOK, gin.H{"status": worklog.IdleStatus, "worklog": worklog})
 }
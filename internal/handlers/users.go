@@ -1,35 +1,44 @@
 package handlers
 
 import (
-	"errors"
+	"encoding/json"
 	"net/http"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/KarmaBeLike/time-tracker-api/config"
 	"github.com/KarmaBeLike/time-tracker-api/internal/models"
 	"github.com/KarmaBeLike/time-tracker-api/internal/service"
-	"github.com/KarmaBeLike/time-tracker-api/pkg/logger"
 	"github.com/gin-gonic/gin"
 )
 
 type UserHandler struct {
 	userService service.UserService
+	admin       *AdminToken
 }
 
-func NewUserHandler(userService service.UserService) *UserHandler {
+func NewUserHandler(userService service.UserService, admin *AdminToken) *UserHandler {
 	return &UserHandler{
 		userService: userService,
+		admin:       admin,
 	}
 }
 
 func (h *UserHandler) Routes(router *gin.Engine, cfg *config.Config) {
 	user := router.Group("/users")
 	{
-		user.POST("/", h.CreateUser)          // @summary Create a new user
-		user.GET("/", h.GetUsers)             // @summary Get list of users
-		user.DELETE("/:userId", h.DeleteUser) // @summary Delete a user
-		user.PUT("/:userId", h.UpdateUser)    // @summary Update a user
+		user.POST("/", h.CreateUser)                 // @summary Create a new user
+		user.GET("/", h.GetUsers)                    // @summary Get list of users
+		user.DELETE("/:userId", h.DeleteUser)        // @summary Delete a user
+		user.PUT("/:userId", h.UpdateUser)           // @summary Update a user
+		user.PATCH("/:userId", h.PatchUser)          // @summary Partially update a user
+		user.POST("/:userId/restore", h.RestoreUser) // @summary Restore a user
+	}
 
+	admin := router.Group("/admin", h.admin.Require)
+	{
+		admin.POST("/users/:userId/purge", h.PurgeUser) // @summary Purge a user
 	}
 }
 
@@ -39,22 +48,19 @@ func (h *UserHandler) Routes(router *gin.Engine, cfg *config.Config) {
 // @Accept  json
 // @Produce  json
 // @Param user body CreateUserRequest true "User info"
-// @Success 200 {object} User
-// @Router /users [post]
+// @Success 200 {object} object{status=string,user=models.User}
+// @Router /users/ [post]
 func (h *UserHandler) CreateUser(c *gin.Context) {
-	logger.PrintInfo("Handling CreateUser request", nil)
+	requestLogger(c).PrintInfo("Handling CreateUser request", nil)
 
-	var json struct {
-		PassportNumber string `json:"passportNumber"`
-	}
-	if err := c.ShouldBindJSON(&json); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+	var req CreateUserRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	user, err := h.userService.CreateUser(json.PassportNumber)
+	user, err := h.userService.CreateUser(c.Request.Context(), req.PassportNumber)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -62,82 +68,171 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 }
 
 // @Summary Get list of users
-// @Description Get a list of users with optional filters and pagination
+// @Description Get a list of users with optional filters, sorting and pagination.
+// @Description Text filters are case-insensitive substring matches, q is a fuzzy (trigram) search over the full name.
+// @Description Pass the returned nextCursor as cursor to fetch the next page instead of using page.
 // @Tags Users
 // @Produce  json
 // @Param name query string false "Name"
+// @Param surname query string false "Surname"
+// @Param patronymic query string false "Patronymic"
+// @Param address query string false "Address"
 // @Param passportNumber query string false "Passport Number"
+// @Param q query string false "Fuzzy search over the full name"
+// @Param createdFrom query string false "Created at or after (RFC 3339)"
+// @Param createdTo query string false "Created at or before (RFC 3339)"
+// @Param active query bool false "Only active (true) or only deleted (false) users"
+// @Param includeDeleted query bool false "Include soft-deleted users"
+// @Param sort query string false "Sort by id, name, surname, patronymic, passportNumber, address, createdAt or relevance"
+// @Param order query string false "asc or desc"
 // @Param page query int false "Page number"
-// @Param page_size query int false "Page size"
-// @Success 200 {object} GetUsersResponse
-// @Router /users [get]
+// @Param limit query int false "Page size"
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {object} object{data=[]models.User,total=int,limit=int,page=int,nextCursor=string}
+// @Router /users/ [get]
 func (h *UserHandler) GetUsers(c *gin.Context) {
 	// Получение параметров запроса
-	logger.PrintInfo("Handling GetUsers request", nil)
-
-	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page parameter"})
-		return
-	}
+	requestLogger(c).PrintInfo("Handling GetUsers request", nil)
 
-	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
+	var query GetUsersQuery
+	if !bindQuery(c, &query) {
 		return
 	}
 
 	// Сбор фильтров из параметров запроса
-	filters := make(map[string]string)
-	if name := c.Query("name"); name != "" {
-		filters["name"] = name
-	}
-	if passportNumber := c.Query("passportNumber"); passportNumber != "" {
-		filters["passportNumber"] = passportNumber
+	params := models.UserListParams{
+		Filter: models.UserFilter{
+			Name:           query.Name,
+			Surname:        query.Surname,
+			Patronymic:     query.Patronymic,
+			Address:        query.Address,
+			PassportNumber: query.PassportNumber,
+			Search:         query.Search,
+			CreatedFrom:    optionalTime(query.CreatedFrom),
+			CreatedTo:      optionalTime(query.CreatedTo),
+			Active:         query.Active,
+			IncludeDeleted: query.IncludeDeleted,
+		},
+		Sort:   query.Sort,
+		Order:  query.Order,
+		Page:   query.Page,
+		Limit:  query.Limit,
+		Cursor: query.Cursor,
 	}
 
 	// Получение пользователей из сервиса
-	users, total, err := h.userService.GetUsers(page, limit, filters)
+	list, err := h.userService.GetUsers(c.Request.Context(), params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	// Формирование ответа с пагинацией
-	c.JSON(http.StatusOK, gin.H{
-		"page":  page,
-		"limit": limit,
-		"total": total,
-		"data":  users,
-	})
+	response := gin.H{
+		"limit": params.Limit,
+		"total": list.Total,
+		"data":  list.Users,
+	}
+	if params.Cursor == "" {
+		response["page"] = params.Page
+	}
+	if list.NextCursor != "" {
+		response["nextCursor"] = list.NextCursor
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// optionalTime treats the zero time of an unset query parameter as nil.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
 }
 
 // @Summary Delete a user
-// @Description Delete a user based on user ID
+// @Description Soft-delete a user based on user ID. The user can be restored later.
 // @Tags Users
 // @Param userId path int true "User ID"
-// @Success 204
+// @Success 200
+// @Failure 404 {object} Problem
 // @Router /users/{userId} [delete]
 func (h *UserHandler) DeleteUser(c *gin.Context) {
-	userId := c.Param("userid")
+	userId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
 
 	// Логирование начала процесса удаления пользователя
-	logger.PrintDebug("Attempting to delete user", map[string]any{"userId": userId})
+	requestLogger(c).PrintDebug("Attempting to delete user", map[string]any{"userId": userId})
 
 	// Вызов метода удаления пользователя из сервиса
-	if err := h.userService.DeleteUser(userId); err != nil {
-		logger.PrintError(errors.New("failed to delete user"), map[string]any{"userId": userId, "error": err.Error()})
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+	err = h.userService.DeleteUser(c.Request.Context(), userId)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
 	// Логирование успешного удаления пользователя
-	logger.PrintInfo("User deleted successfully", map[string]any{"userId": userId})
+	requestLogger(c).PrintInfo("User deleted successfully", map[string]any{"userId": userId})
 
 	// Возврат успешного ответа
 	c.JSON(http.StatusOK, gin.H{"status": "deleted", "userId": userId})
 }
 
+// @Summary Restore a user
+// @Description Restore a soft-deleted user based on user ID
+// @Tags Users
+// @Param userId path int true "User ID"
+// @Success 200
+// @Failure 404 {object} Problem
+// @Router /users/{userId}/restore [post]
+func (h *UserHandler) RestoreUser(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	err = h.userService.RestoreUser(c.Request.Context(), userId)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	requestLogger(c).PrintInfo("User restored successfully", map[string]any{"userId": userId})
+
+	c.JSON(http.StatusOK, gin.H{"status": "restored", "userId": userId})
+}
+
+// @Summary Purge a user
+// @Description Anonymise the personal data of a user. Worklogs are kept so totals stay intact. This can't be undone.
+// @Tags Admin
+// @Security AdminToken
+// @Param userId path int true "User ID"
+// @Success 200
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Router /admin/users/{userId}/purge [post]
+func (h *UserHandler) PurgeUser(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	err = h.userService.PurgeUser(c.Request.Context(), userId)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	requestLogger(c).PrintInfo("User purged successfully", map[string]any{"userId": userId})
+
+	c.JSON(http.StatusOK, gin.H{"status": "purged", "userId": userId})
+}
+
 // @Summary Update a user
 // @Description Update user information based on user ID
 // @Tags Users
@@ -145,40 +240,120 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 // @Produce  json
 // @Param userId path int true "User ID"
 // @Param user body UpdateUserRequest true "User info"
-// @Success 200 {object} User
+// @Success 200 {object} object{status=string,user=models.User}
+// @Failure 404 {object} Problem
 // @Router /users/{userId} [put]
 func (h *UserHandler) UpdateUser(c *gin.Context) {
 	userId, err := strconv.Atoi(c.Param("userId"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(errInvalidUserID)
 		return
 	}
 
-	var json struct {
-		Name           string `json:"name"`
-		Surname        string `json:"surname"`
-		Patronymic     string `json:"patronymic"`
-		PassportNumber string `json:"passportNumber"`
-		Address        string `json:"address"`
-	}
-	if err := c.ShouldBindJSON(&json); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req UpdateUserRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	user := &models.User{
 		ID:             userId,
-		Name:           json.Name,
-		Surname:        json.Surname,
-		Patronymic:     json.Patronymic,
-		PassportNumber: json.PassportNumber,
-		Address:        json.Address,
+		Name:           req.Name,
+		Surname:        req.Surname,
+		Patronymic:     req.Patronymic,
+		PassportNumber: req.PassportNumber,
+		Address:        req.Address,
+		Team:           req.Team,
 	}
 
-	if err := h.userService.UpdateUser(user); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.userService.UpdateUser(c.Request.Context(), user); err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"status": "updated", "user": user})
 }
+
+// @Summary Partially update a user
+// @Description Update only the provided user fields using JSON Merge Patch semantics. A null value clears the field.
+// @Tags Users
+// @Accept  json
+// @Accept  application/merge-patch+json
+// @Produce  json
+// @Param userId path int true "User ID"
+// @Param user body UpdateUserRequest true "Fields to update"
+// @Success 200 {object} object{status=string,user=models.User}
+// @Failure 400 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 409 {object} Problem
+// @Router /users/{userId} [patch]
+func (h *UserHandler) PatchUser(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.NewDecoder(c.Request.Body).Decode(&body); err != nil {
+		c.Error(service.NewValidationError("invalid_patch", "patch must be a JSON object"))
+		return
+	}
+
+	patch, err := decodeUserPatch(body)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	user, err := h.userService.PatchUser(c.Request.Context(), userId, patch)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "updated", "user": user})
+}
+
+// decodeUserPatch maps the members of a merge patch document onto a
+// models.UserPatch. A JSON null clears the field. Every rejected member is
+// reported as a field error.
+func decodeUserPatch(body map[string]json.RawMessage) (models.UserPatch, error) {
+	var patch models.UserPatch
+	fields := map[string]**string{
+		"name":           &patch.Name,
+		"surname":        &patch.Surname,
+		"patronymic":     &patch.Patronymic,
+		"passportNumber": &patch.PassportNumber,
+		"address":        &patch.Address,
+		"team":           &patch.Team,
+	}
+
+	var errs []service.FieldError
+	for key, raw := range body {
+		field, ok := fields[key]
+		if !ok {
+			errs = append(errs, service.FieldError{Field: key, Message: "is not a known field"})
+			continue
+		}
+
+		var value *string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			errs = append(errs, service.FieldError{Field: key, Message: "must be a string or null"})
+			continue
+		}
+		if value == nil {
+			value = new(string)
+		}
+		if fe := validateVar(key, *value, userPatchRules[key]); fe != nil {
+			errs = append(errs, *fe)
+			continue
+		}
+		*field = value
+	}
+
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+		return patch, service.NewFieldsError(errs)
+	}
+	return patch, nil
+}
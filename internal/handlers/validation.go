@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	// Имена полей в ошибках берутся из тегов json/form, как их видит клиент
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			name := strings.Split(field.Tag.Get(tag), ",")[0]
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+
+	v.RegisterValidation("passport", func(fl validator.FieldLevel) bool {
+		return service.PassportPattern.MatchString(fl.Field().String())
+	})
+
+	// mindur=1m: длительность не меньше указанной
+	v.RegisterValidation("mindur", func(fl validator.FieldLevel) bool {
+		min, err := time.ParseDuration(fl.Param())
+		if err != nil {
+			return false
+		}
+		return time.Duration(fl.Field().Int()) >= min
+	})
+}
+
+// bindJSON decodes and validates the request body into obj. On failure the
+// error is attached to the context and false is returned.
+func bindJSON(c *gin.Context, obj any) bool {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		c.Error(validationError(err, errInvalidBody))
+		return false
+	}
+	return true
+}
+
+// bindQuery decodes and validates the query string into obj. On failure the
+// error is attached to the context and false is returned.
+func bindQuery(c *gin.Context, obj any) bool {
+	if err := c.ShouldBindQuery(obj); err != nil {
+		c.Error(validationError(err, errInvalidQuery))
+		return false
+	}
+	return true
+}
+
+// validateVar checks a single value against a validator rule and returns the
+// field error, if any.
+func validateVar(field string, value any, rule string) *service.FieldError {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return nil
+	}
+	var errs validator.ValidationErrors
+	if err := v.Var(value, rule); errors.As(err, &errs) {
+		return &service.FieldError{Field: field, Message: fieldMessage(errs[0])}
+	}
+	return nil
+}
+
+// validationError turns validation errors into a domain validation error with
+// one entry per rejected field. Decoding errors, which can't be attributed to
+// a field, are reported as fallback.
+func validationError(err error, fallback *service.Error) error {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return fallback.Wrap(err)
+	}
+
+	fields := make([]service.FieldError, 0, len(errs))
+	for _, fe := range errs {
+		fields = append(fields, service.FieldError{Field: fe.Field(), Message: fieldMessage(fe)})
+	}
+	return service.NewFieldsError(fields)
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return "must be at least " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param()
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "passport":
+		return `must be a passport series and number, e.g. "1234 567890"`
+	case "datetime":
+		return "must be a date in the format " + fe.Param()
+	case "mindur":
+		return "must be a duration of at least " + fe.Param()
+	case "gtefield":
+		return "must not be before " + lowerFirst(fe.Param())
+	case "required_without":
+		return "is required unless " + lowerFirst(fe.Param()) + " is set"
+	case "excluded_with":
+		return "must not be set together with " + lowerFirst(fe.Param())
+	default:
+		return fmt.Sprintf("failed the %q rule", fe.Tag())
+	}
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
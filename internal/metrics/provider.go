@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/external"
+)
+
+// instrumentedProvider records People API metrics around another provider.
+type instrumentedProvider struct {
+	next    external.PersonInfoProvider
+	metrics *Metrics
+}
+
+// InstrumentProvider wraps a person info provider with duration and error
+// metrics. Lookups of unknown passports are not counted as errors.
+func (m *Metrics) InstrumentProvider(next external.PersonInfoProvider) external.PersonInfoProvider {
+	return &instrumentedProvider{next: next, metrics: m}
+}
+
+func (p *instrumentedProvider) GetPersonInfo(ctx context.Context, passportNumber string) (*external.PeopleResponse, error) {
+	start := time.Now()
+	person, err := p.next.GetPersonInfo(ctx, passportNumber)
+
+	observed := err
+	if errors.Is(err, external.ErrPersonNotFound) {
+		observed = nil
+	}
+	p.metrics.ObserveUpstream("people_api", "get_person_info", start, observed)
+	return person, err
+}
+
+// Ping is forwarded so that readiness checks still see the wrapped provider.
+func (p *instrumentedProvider) Ping(ctx context.Context) error {
+	pinger, ok := p.next.(external.Pinger)
+	if !ok {
+		return nil
+	}
+	start := time.Now()
+	err := pinger.Ping(ctx)
+	p.metrics.ObserveUpstream("people_api", "ping", start, err)
+	return err
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+const (
+	SearchHitTask    = "task"
+	SearchHitWorklog = "worklog"
+)
+
+// SearchParams describes a full-text search over task names, descriptions
+// and worklog notes. Zero values mean "no filter".
+type SearchParams struct {
+	// Query is a web search style query: words, "quoted phrases", or and -.
+	Query string
+	// UserID limits the worklogs to those of the user, and the tasks to those
+	// the user logged time on.
+	UserID int
+	// From and To limit the worklogs to those started in [From, To), and the
+	// tasks to those with such worklogs.
+	From  *time.Time
+	To    *time.Time
+	Limit int
+}
+
+// SearchHit is a task or a worklog matching a search, best matches first.
+type SearchHit struct {
+	// Type is SearchHitTask or SearchHitWorklog.
+	Type      string     `json:"type"`
+	TaskID    int        `json:"taskId"`
+	TaskName  string     `json:"taskName"`
+	WorklogID int        `json:"worklogId,omitempty"`
+	UserID    int        `json:"userId,omitempty"`
+	StartTime *time.Time `json:"startTime,omitempty"`
+	EndTime   *time.Time `json:"endTime,omitempty"`
+	Rank      float64    `json:"rank"`
+	// Snippet is an HTML excerpt of the matching text, the task name and
+	// description or the worklog note, with the matches in <b> tags.
+	Snippet string `json:"snippet"`
+}
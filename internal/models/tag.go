@@ -0,0 +1,20 @@
+package models
+
+// Tag is a label a user puts on worklogs and tasks to tell kinds of work
+// apart. Tags belong to the user who created them.
+type Tag struct {
+	ID     int    `json:"id"`
+	UserID int    `json:"userId"`
+	Name   string `json:"name"`
+	// Color is a hex RGB color like "#1e88e5".
+	Color string `json:"color"`
+}
+
+// TagTotal is the time a user spent on worklogs with a tag. A worklog counts
+// towards every tag of its own and of its task.
+type TagTotal struct {
+	// Tag is nil for the time without any tag.
+	Tag          *Tag `json:"tag"`
+	TotalHours   int  `json:"total_hours"`
+	TotalMinutes int  `json:"total_minutes"`
+}
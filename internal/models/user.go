@@ -6,12 +6,62 @@ import (
 
 // User represents the structure of a user in the system.
 type User struct {
-	ID             int       `json:"id"`
-	Name           string    `json:"name"`
-	PassportNumber string    `json:"passportNumber"`
-	Surname        string    `json:"surname"`
-	Patronymic     string    `json:"patronymic"`
-	Address        string    `json:"address"`
-	CreatedAt      time.Time `json:"createdAt"`
-	TaskIDs        []int     `json:"taskIds"`
+	ID             int        `json:"id"`
+	Name           string     `json:"name"`
+	PassportNumber string     `json:"passportNumber"`
+	Surname        string     `json:"surname"`
+	Patronymic     string     `json:"patronymic"`
+	Address        string     `json:"address"`
+	Team           string     `json:"team,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	TaskIDs        []int      `json:"taskIds"`
+	DeletedAt      *time.Time `json:"deletedAt,omitempty"`
+}
+
+// UserPatch holds the fields of a JSON Merge Patch (RFC 7396) for a user.
+// A nil field was absent from the patch and is left untouched.
+type UserPatch struct {
+	Name           *string
+	Surname        *string
+	Patronymic     *string
+	PassportNumber *string
+	Address        *string
+	Team           *string
+}
+
+// UserFilter narrows down the users returned by a listing. Zero values mean
+// "no filter".
+type UserFilter struct {
+	Name           string
+	Surname        string
+	Patronymic     string
+	Address        string
+	PassportNumber string
+	// Search is matched by trigram similarity against the full name.
+	Search      string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	// Active selects only active (true) or only deleted (false) users.
+	// When nil, deleted users are hidden unless IncludeDeleted is set.
+	Active         *bool
+	IncludeDeleted bool
+}
+
+// UserListParams describes a page of users. Cursor takes precedence over
+// Page when both are set.
+type UserListParams struct {
+	Filter UserFilter
+	Sort   string
+	Order  string
+	Page   int
+	Limit  int
+	Cursor string
+}
+
+// UserList is a page of users together with the total number of matches and
+// the cursor of the next page, if there is one.
+type UserList struct {
+	Users      []User
+	Total      int
+	NextCursor string
 }
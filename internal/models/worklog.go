@@ -0,0 +1,89 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Worklog is a single start/stop interval of a user working on a task.
+type Worklog struct {
+	ID             int        `json:"id"`
+	UserID         int        `json:"userId"`
+	TaskID         int        `json:"taskId"`
+	TaskName       string     `json:"taskName,omitempty"`
+	StartTime      time.Time  `json:"startTime"`
+	EndTime        *time.Time `json:"endTime,omitempty"`
+	LastActivityAt *time.Time `json:"lastActivityAt,omitempty"`
+	AutoStopped    bool       `json:"autoStopped"`
+	// IdleSince is where the idle tail of the worklog starts, if the user was
+	// found idle. IdleStatus tells what happened to it.
+	IdleSince  *time.Time `json:"idleSince,omitempty"`
+	IdleStatus string     `json:"idleStatus,omitempty"`
+	// Note is what the user wrote when starting and stopping the timer.
+	Note string `json:"note,omitempty"`
+	Tags []Tag  `json:"tags,omitempty"`
+}
+
+const (
+	IdleProposed  = "proposed"
+	IdleAccepted  = "accepted"
+	IdleDiscarded = "discarded"
+	IdleApplied   = "applied"
+)
+
+// OpenWorklog is a running timer together with the auto-stop policy that
+// applies to its owner, if any was configured.
+type OpenWorklog struct {
+	Worklog
+	Policy *AutoStopPolicy
+}
+
+const (
+	StopAtCap          = "cap"
+	StopAtLastActivity = "last_activity"
+)
+
+// AutoStopPolicy decides when a running timer is considered abandoned and
+// where it is cut off. It is attached either to a user or to a team.
+type AutoStopPolicy struct {
+	ID     int    `json:"id"`
+	UserID *int   `json:"userId,omitempty"`
+	Team   string `json:"team,omitempty"`
+	// MaxDuration is how long a timer may run before it is auto-stopped.
+	MaxDuration Duration `json:"maxDuration" swaggertype:"string" example:"12h"`
+	// StopAt is either StopAtCap or StopAtLastActivity.
+	StopAt string `json:"stopAt"`
+	// Cap is the length the worklog is cut to when StopAt is StopAtCap, and
+	// the fallback when there was no recorded activity.
+	Cap Duration `json:"cap" swaggertype:"string" example:"8h"`
+}
+
+// Duration is a time.Duration that is encoded in JSON as a string like "8h30m".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// AutoStopNotice tells the owner of a timer that it was stopped for them.
+type AutoStopNotice struct {
+	UserID    int       `json:"userId"`
+	WorklogID int       `json:"worklogId"`
+	TaskID    int       `json:"taskId"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	StopAt    string    `json:"stopAt"`
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+)
+
+var ErrPolicyNotFound = errors.New("auto-stop policy not found")
+
+type AutoStopPolicyRepository interface {
+	GetPolicies(ctx context.Context) ([]models.AutoStopPolicy, error)
+	SavePolicy(ctx context.Context, policy *models.AutoStopPolicy) error
+	DeleteUserPolicy(ctx context.Context, userId int) error
+	DeleteTeamPolicy(ctx context.Context, team string) error
+}
+
+type autoStopPolicyRepository struct {
+	db       *sql.DB
+	timeouts Timeouts
+}
+
+func NewAutoStopPolicyRepository(db *sql.DB, timeouts Timeouts) AutoStopPolicyRepository {
+	return &autoStopPolicyRepository{db: db, timeouts: timeouts}
+}
+
+func (r *autoStopPolicyRepository) GetPolicies(ctx context.Context) (_ []models.AutoStopPolicy, err error) {
+	ctx, end := begin(ctx, "autoStopPolicyRepository.GetPolicies", r.timeouts.Query)
+	defer end(&err)
+
+	query := `
+		SELECT id, user_id, COALESCE(team, ''), max_duration_seconds, stop_at, cap_seconds
+		FROM auto_stop_policies
+		ORDER BY id
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []models.AutoStopPolicy
+
+	for rows.Next() {
+		var policy nullablePolicy
+		if err := rows.Scan(&policy.ID, &policy.UserID, &policy.Team, &policy.MaxDurationSeconds, &policy.StopAt, &policy.CapSeconds); err != nil {
+			return nil, err
+		}
+		policies = append(policies, *policy.toModel())
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// SavePolicy creates or replaces the policy of policy.UserID or policy.Team.
+func (r *autoStopPolicyRepository) SavePolicy(ctx context.Context, policy *models.AutoStopPolicy) (err error) {
+	ctx, end := begin(ctx, "autoStopPolicyRepository.SavePolicy", r.timeouts.Query)
+	defer end(&err)
+
+	conflict := "(team)"
+	if policy.UserID != nil {
+		conflict = "(user_id)"
+	}
+	query := `
+		INSERT INTO auto_stop_policies (user_id, team, max_duration_seconds, stop_at, cap_seconds)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5)
+		ON CONFLICT ` + conflict + ` DO UPDATE
+		SET max_duration_seconds = EXCLUDED.max_duration_seconds, stop_at = EXCLUDED.stop_at, cap_seconds = EXCLUDED.cap_seconds
+		RETURNING id
+	`
+	err = r.db.QueryRowContext(ctx, query, policy.UserID, policy.Team,
+		int(time.Duration(policy.MaxDuration).Seconds()), policy.StopAt, int(time.Duration(policy.Cap).Seconds())).Scan(&policy.ID)
+	if _, ok := isPQError(err, pqForeignKeyViolation); ok {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+func (r *autoStopPolicyRepository) DeleteUserPolicy(ctx context.Context, userId int) (err error) {
+	ctx, end := begin(ctx, "autoStopPolicyRepository.DeleteUserPolicy", r.timeouts.Query)
+	defer end(&err)
+
+	return r.deletePolicy(ctx, "DELETE FROM auto_stop_policies WHERE user_id = $1", userId)
+}
+
+func (r *autoStopPolicyRepository) DeleteTeamPolicy(ctx context.Context, team string) (err error) {
+	ctx, end := begin(ctx, "autoStopPolicyRepository.DeleteTeamPolicy", r.timeouts.Query)
+	defer end(&err)
+
+	return r.deletePolicy(ctx, "DELETE FROM auto_stop_policies WHERE team = $1", team)
+}
+
+func (r *autoStopPolicyRepository) deletePolicy(ctx context.Context, query string, arg any) error {
+	result, err := r.db.ExecContext(ctx, query, arg)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrPolicyNotFound
+	}
+	return nil
+}
+
+// nullablePolicy scans an auto_stop_policies row that may come from an outer
+// join and therefore be entirely NULL.
+type nullablePolicy struct {
+	ID                 sql.NullInt64
+	UserID             sql.NullInt64
+	Team               sql.NullString
+	MaxDurationSeconds sql.NullInt64
+	StopAt             sql.NullString
+	CapSeconds         sql.NullInt64
+}
+
+func (p nullablePolicy) toModel() *models.AutoStopPolicy {
+	if !p.ID.Valid {
+		return nil
+	}
+	policy := &models.AutoStopPolicy{
+		ID:          int(p.ID.Int64),
+		Team:        p.Team.String,
+		MaxDuration: models.Duration(time.Duration(p.MaxDurationSeconds.Int64) * time.Second),
+		StopAt:      p.StopAt.String,
+		Cap:         models.Duration(time.Duration(p.CapSeconds.Int64) * time.Second),
+	}
+	if p.UserID.Valid {
+		userId := int(p.UserID.Int64)
+		policy.UserID = &userId
+	}
+	return policy
+}
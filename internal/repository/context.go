@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Timeouts bounds how long a single repository call may run. Reports over
+// worklogs get their own, usually longer, limit. Zero disables a limit.
+type Timeouts struct {
+	Query  time.Duration
+	Report time.Duration
+}
+
+// begin opens a span for a repository call and cuts its context off after
+// timeout. Statements run with the returned context, so a cancelled request
+// or an expired timeout cancels them on the server too. The returned func
+// must be deferred with the address of the call's error, which marks the span
+// as failed.
+func begin(ctx context.Context, name string, timeout time.Duration) (context.Context, func(*error)) {
+	ctx, span := tracing.Start(ctx, name, attribute.String("db.system", "postgresql"))
+	cancel := context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	return ctx, func(err *error) {
+		cancel()
+		tracing.End(span, *err)
+	}
+}
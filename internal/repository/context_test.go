@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestBeginMarksFailedSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	call := func(name string, result error) (err error) {
+		_, end := begin(context.Background(), name, 0)
+		defer end(&err)
+		return result
+	}
+	call("ok", nil)
+	call("failed", errors.New("connection refused"))
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	if status := spans[0].Status(); status.Code != codes.Unset {
+		t.Fatalf("status of a successful call = %+v", status)
+	}
+	if status := spans[1].Status(); status.Code != codes.Error || status.Description != "connection refused" || len(spans[1].Events()) != 1 {
+		t.Fatalf("status of a failed call = %+v, events %v", status, spans[1].Events())
+	}
+}
@@ -0,0 +1,950 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+)
+
+// fixture is an empty data set with repositories on top of it. The contract
+// tests below run against every implementation, so that the in-memory ones
+// can stand in for Postgres.
+type fixture struct {
+	users    UserRepository
+	tasks    TaskRepository
+	policies AutoStopPolicyRepository
+	tags     TagRepository
+	search   SearchRepository
+	// addTask creates a task; the repositories have no method for that.
+	addTask func(t *testing.T, name, description string) int
+}
+
+func testRepositories(t *testing.T, newFixture func(t *testing.T) fixture) {
+	t.Run("UserRepository", func(t *testing.T) { testUserRepository(t, newFixture) })
+	t.Run("TaskRepository", func(t *testing.T) { testTaskRepository(t, newFixture) })
+	t.Run("AutoStopPolicyRepository", func(t *testing.T) { testAutoStopPolicyRepository(t, newFixture) })
+	t.Run("TagRepository", func(t *testing.T) { testTagRepository(t, newFixture) })
+	t.Run("SearchRepository", func(t *testing.T) { testSearchRepository(t, newFixture) })
+}
+
+func createUser(t *testing.T, f fixture, passport, surname, name string) *models.User {
+	t.Helper()
+	user := &models.User{PassportNumber: passport, Surname: surname, Name: name, Patronymic: "Ivanovich", Address: "Moscow"}
+	if err := f.users.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("CreateUser(%q): %v", passport, err)
+	}
+	return user
+}
+
+func wantErr(t *testing.T, what string, err, want error) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Fatalf("%s: got error %v, want %v", what, err, want)
+	}
+}
+
+func noErr(t *testing.T, what string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", what, err)
+	}
+}
+
+func userIDs(users []models.User) []int {
+	ids := make([]int, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	return ids
+}
+
+func sameIDs(a, b []int) bool {
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func testUserRepository(t *testing.T, newFixture func(t *testing.T) fixture) {
+	ctx := context.Background()
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		f := newFixture(t)
+		user := createUser(t, f, "1234 567890", "Ivanov", "Ivan")
+		if user.ID == 0 || user.CreatedAt.IsZero() {
+			t.Fatalf("CreateUser didn't set ID and CreatedAt: %+v", user)
+		}
+
+		got, err := f.users.GetUserByID(ctx, user.ID)
+		noErr(t, "GetUserByID", err)
+		if got.PassportNumber != user.PassportNumber || got.Surname != "Ivanov" || got.Name != "Ivan" ||
+			got.Patronymic != "Ivanovich" || got.Address != "Moscow" || !got.CreatedAt.Equal(user.CreatedAt) || got.DeletedAt != nil {
+			t.Fatalf("GetUserByID = %+v, want %+v", got, user)
+		}
+
+		got, err = f.users.GetUserByPassport(ctx, "1234 567890")
+		noErr(t, "GetUserByPassport", err)
+		if got.ID != user.ID {
+			t.Fatalf("GetUserByPassport returned user %d, want %d", got.ID, user.ID)
+		}
+
+		_, err = f.users.GetUserByID(ctx, user.ID+100)
+		wantErr(t, "GetUserByID of a missing user", err, ErrUserNotFound)
+		_, err = f.users.GetUserByPassport(ctx, "0000 000000")
+		wantErr(t, "GetUserByPassport of a missing user", err, ErrUserNotFound)
+	})
+
+	t.Run("UniquePassport", func(t *testing.T) {
+		f := newFixture(t)
+		user := createUser(t, f, "1234 567890", "Ivanov", "Ivan")
+
+		err := f.users.CreateUser(ctx, &models.User{PassportNumber: "1234 567890"})
+		wantErr(t, "CreateUser with a taken passport", err, ErrPassportExists)
+
+		// Паспорт удалённого пользователя тоже занят
+		noErr(t, "DeleteUser", f.users.DeleteUser(ctx, user.ID))
+		err = f.users.CreateUser(ctx, &models.User{PassportNumber: "1234 567890"})
+		wantErr(t, "CreateUser with the passport of a deleted user", err, ErrPassportExists)
+	})
+
+	t.Run("ConcurrentCreate", func(t *testing.T) {
+		f := newFixture(t)
+		const n = 10
+		errs := make([]error, n)
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = f.users.CreateUser(ctx, &models.User{PassportNumber: "1234 567890", Name: fmt.Sprint(i)})
+			}(i)
+		}
+		wg.Wait()
+
+		created := 0
+		for _, err := range errs {
+			switch {
+			case err == nil:
+				created++
+			case !errors.Is(err, ErrPassportExists):
+				t.Fatalf("CreateUser: %v", err)
+			}
+		}
+		if created != 1 {
+			t.Fatalf("%d users were created with the same passport, want 1", created)
+		}
+	})
+
+	t.Run("DeleteAndRestore", func(t *testing.T) {
+		f := newFixture(t)
+		user := createUser(t, f, "1234 567890", "Ivanov", "Ivan")
+
+		wantErr(t, "RestoreUser of an active user", f.users.RestoreUser(ctx, user.ID), ErrUserNotFound)
+		noErr(t, "DeleteUser", f.users.DeleteUser(ctx, user.ID))
+		wantErr(t, "DeleteUser of a deleted user", f.users.DeleteUser(ctx, user.ID), ErrUserNotFound)
+		wantErr(t, "DeleteUser of a missing user", f.users.DeleteUser(ctx, user.ID+100), ErrUserNotFound)
+
+		_, err := f.users.GetUserByID(ctx, user.ID)
+		wantErr(t, "GetUserByID of a deleted user", err, ErrUserNotFound)
+		got, err := f.users.GetUserByPassport(ctx, user.PassportNumber)
+		noErr(t, "GetUserByPassport of a deleted user", err)
+		if got.DeletedAt == nil {
+			t.Fatal("GetUserByPassport of a deleted user has no DeletedAt")
+		}
+
+		noErr(t, "RestoreUser", f.users.RestoreUser(ctx, user.ID))
+		got, err = f.users.GetUserByID(ctx, user.ID)
+		noErr(t, "GetUserByID of a restored user", err)
+		if got.DeletedAt != nil {
+			t.Fatalf("restored user has DeletedAt %v", got.DeletedAt)
+		}
+	})
+
+	t.Run("Purge", func(t *testing.T) {
+		f := newFixture(t)
+		user := createUser(t, f, "1234 567890", "Ivanov", "Ivan")
+
+		noErr(t, "PurgeUser", f.users.PurgeUser(ctx, user.ID))
+		wantErr(t, "PurgeUser of a purged user", f.users.PurgeUser(ctx, user.ID), ErrUserNotFound)
+		wantErr(t, "RestoreUser of a purged user", f.users.RestoreUser(ctx, user.ID), ErrUserNotFound)
+
+		got, err := f.users.GetUserByPassport(ctx, fmt.Sprintf("purged-%d", user.ID))
+		noErr(t, "GetUserByPassport of a purged user", err)
+		if got.Name != "" || got.Surname != "" || got.Patronymic != "" || got.Address != "" || got.DeletedAt == nil {
+			t.Fatalf("purged user wasn't anonymised: %+v", got)
+		}
+
+		// Паспорт освобождается
+		createUser(t, f, "1234 567890", "Ivanov", "Ivan")
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		f := newFixture(t)
+		user := createUser(t, f, "1234 567890", "Ivanov", "Ivan")
+		other := createUser(t, f, "1111 111111", "Petrov", "Petr")
+
+		user.Name, user.Team = "Ivan II", "core"
+		noErr(t, "UpdateUser", f.users.UpdateUser(ctx, user))
+		got, err := f.users.GetUserByID(ctx, user.ID)
+		noErr(t, "GetUserByID", err)
+		if got.Name != "Ivan II" || got.Team != "core" {
+			t.Fatalf("GetUserByID after UpdateUser = %+v", got)
+		}
+
+		user.PassportNumber = other.PassportNumber
+		wantErr(t, "UpdateUser to a taken passport", f.users.UpdateUser(ctx, user), ErrPassportExists)
+
+		noErr(t, "DeleteUser", f.users.DeleteUser(ctx, other.ID))
+		other.Name = "changed"
+		wantErr(t, "UpdateUser of a deleted user", f.users.UpdateUser(ctx, other), ErrUserNotFound)
+		got, err = f.users.GetUserByPassport(ctx, other.PassportNumber)
+		noErr(t, "GetUserByPassport", err)
+		if got.Name != "Petr" {
+			t.Fatalf("UpdateUser changed a deleted user: %+v", got)
+		}
+		missing := *user
+		missing.ID += 100
+		wantErr(t, "UpdateUser of a missing user", f.users.UpdateUser(ctx, &missing), ErrUserNotFound)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		f := newFixture(t)
+		ivanov := createUser(t, f, "1000 000001", "Ivanov", "Ivan")
+		petrov := createUser(t, f, "1000 000002", "Petrov", "Petr")
+		sidorov := createUser(t, f, "1000 000003", "Sidorov", "Sidor")
+		deleted := createUser(t, f, "1000 000004", "Ivanova", "Anna")
+		noErr(t, "DeleteUser", f.users.DeleteUser(ctx, deleted.ID))
+
+		active, inactive := true, false
+		for _, tc := range []struct {
+			name   string
+			params models.UserListParams
+			want   []int
+		}{
+			{"all active", models.UserListParams{}, []int{ivanov.ID, petrov.ID, sidorov.ID}},
+			{"include deleted", models.UserListParams{Filter: models.UserFilter{IncludeDeleted: true}}, []int{ivanov.ID, petrov.ID, sidorov.ID, deleted.ID}},
+			{"only deleted", models.UserListParams{Filter: models.UserFilter{Active: &inactive}}, []int{deleted.ID}},
+			{"only active", models.UserListParams{Filter: models.UserFilter{Active: &active, IncludeDeleted: true}}, []int{ivanov.ID, petrov.ID, sidorov.ID}},
+			{"surname contains", models.UserListParams{Filter: models.UserFilter{Surname: "ov", IncludeDeleted: true}}, []int{ivanov.ID, petrov.ID, sidorov.ID, deleted.ID}},
+			{"case-insensitive", models.UserListParams{Filter: models.UserFilter{Surname: "IVAN"}}, []int{ivanov.ID}},
+			{"like wildcards are literal", models.UserListParams{Filter: models.UserFilter{Name: "%"}}, []int{}},
+			{"passport", models.UserListParams{Filter: models.UserFilter{PassportNumber: "1000 000002"}}, []int{petrov.ID}},
+			{"search", models.UserListParams{Filter: models.UserFilter{Search: "Sidorov Sidr"}}, []int{sidorov.ID}},
+			{"sort", models.UserListParams{Sort: "surname", Order: "desc"}, []int{sidorov.ID, petrov.ID, ivanov.ID}},
+			{"page", models.UserListParams{Page: 2, Limit: 2}, []int{sidorov.ID}},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				params := tc.params
+				if params.Limit == 0 {
+					params.Page, params.Limit = 1, 10
+				}
+				list, err := f.users.GetUsers(ctx, params)
+				noErr(t, "GetUsers", err)
+				if got := userIDs(list.Users); !sameIDs(got, tc.want) {
+					t.Fatalf("GetUsers returned users %v, want %v", got, tc.want)
+				}
+				if tc.name != "page" && list.Total != len(tc.want) {
+					t.Fatalf("GetUsers total = %d, want %d", list.Total, len(tc.want))
+				}
+			})
+		}
+
+		t.Run("cursor", func(t *testing.T) {
+			params := models.UserListParams{Sort: "surname", Page: 1, Limit: 2, Filter: models.UserFilter{IncludeDeleted: true}}
+			var got []int
+			for i := 0; i < 4; i++ {
+				list, err := f.users.GetUsers(ctx, params)
+				noErr(t, "GetUsers", err)
+				if list.Total != 4 {
+					t.Fatalf("GetUsers total = %d, want 4", list.Total)
+				}
+				got = append(got, userIDs(list.Users)...)
+				if list.NextCursor == "" {
+					break
+				}
+				params.Cursor = list.NextCursor
+			}
+			if want := []int{ivanov.ID, deleted.ID, petrov.ID, sidorov.ID}; !sameIDs(got, want) {
+				t.Fatalf("paging by cursor returned users %v, want %v", got, want)
+			}
+
+			params.Order = "desc"
+			_, err := f.users.GetUsers(ctx, params)
+			wantErr(t, "GetUsers with the cursor of another order", err, ErrInvalidCursor)
+			params.Cursor = "garbage"
+			_, err = f.users.GetUsers(ctx, params)
+			wantErr(t, "GetUsers with an invalid cursor", err, ErrInvalidCursor)
+		})
+
+		t.Run("invalid sort", func(t *testing.T) {
+			for _, params := range []models.UserListParams{
+				{Sort: "password", Page: 1, Limit: 10},
+				{Order: "sideways", Page: 1, Limit: 10},
+				{Sort: SortRelevance, Page: 1, Limit: 10},
+			} {
+				_, err := f.users.GetUsers(ctx, params)
+				wantErr(t, fmt.Sprintf("GetUsers(sort %q, order %q)", params.Sort, params.Order), err, ErrInvalidSort)
+			}
+		})
+	})
+}
+
+// at returns a fixed time on the given day of March 2024, in local time.
+func at(day, hour, minute int) time.Time {
+	return time.Date(2024, time.March, day, hour, minute, 0, 0, time.Local)
+}
+
+func testTaskRepository(t *testing.T, newFixture func(t *testing.T) fixture) {
+	ctx := context.Background()
+
+	t.Run("StartAndStop", func(t *testing.T) {
+		f := newFixture(t)
+		user := createUser(t, f, "1234 567890", "Ivanov", "Ivan")
+		taskId := f.addTask(t, "Invoices", "")
+
+		wantErr(t, "StartTask of a missing task", f.tasks.StartTask(ctx, user.ID, taskId+100, at(1, 9, 0), "", nil), ErrTaskNotFound)
+		wantErr(t, "StartTask of a missing user", f.tasks.StartTask(ctx, user.ID+100, taskId, at(1, 9, 0), "", nil), ErrTaskNotFound)
+		wantErr(t, "StopTask without a timer", f.tasks.StopTask(ctx, user.ID, taskId, at(1, 10, 0), ""), ErrNoRunningTask)
+
+		noErr(t, "StartTask", f.tasks.StartTask(ctx, user.ID, taskId, at(1, 9, 0), "", nil))
+		running, err := f.tasks.GetRunningWorklogs(ctx, user.ID)
+		noErr(t, "GetRunningWorklogs", err)
+		if len(running) != 1 || running[0].TaskID != taskId || running[0].TaskName != "Invoices" ||
+			!running[0].StartTime.Equal(at(1, 9, 0)) || running[0].LastActivityAt == nil || !running[0].LastActivityAt.Equal(at(1, 9, 0)) {
+			t.Fatalf("GetRunningWorklogs = %+v", running)
+		}
+		if count, err := f.tasks.CountOpenWorklogs(ctx); err != nil || count != 1 {
+			t.Fatalf("CountOpenWorklogs = %d, %v; want 1", count, err)
+		}
+
+		noErr(t, "StopTask", f.tasks.StopTask(ctx, user.ID, taskId, at(1, 10, 0), ""))
+		wantErr(t, "StopTask of a stopped timer", f.tasks.StopTask(ctx, user.ID, taskId, at(1, 11, 0), ""), ErrNoRunningTask)
+
+		worklog, err := f.tasks.GetWorklog(ctx, user.ID, running[0].ID)
+		noErr(t, "GetWorklog", err)
+		if worklog.EndTime == nil || !worklog.EndTime.Equal(at(1, 10, 0)) || worklog.AutoStopped {
+			t.Fatalf("GetWorklog after StopTask = %+v", worklog)
+		}
+		running, err = f.tasks.GetRunningWorklogs(ctx, user.ID)
+		noErr(t, "GetRunningWorklogs", err)
+		if len(running) != 0 {
+			t.Fatalf("GetRunningWorklogs after StopTask = %+v", running)
+		}
+
+		_, err = f.tasks.GetWorklog(ctx, user.ID+1, worklog.ID)
+		wantErr(t, "GetWorklog of another user", err, ErrWorklogNotFound)
+	})
+
+	t.Run("ConcurrentStart", func(t *testing.T) {
+		f := newFixture(t)
+		user := createUser(t, f, "1234 567890", "Ivanov", "Ivan")
+		const n = 10
+		var wg sync.WaitGroup
+		errs := make([]error, n)
+		for i := 0; i < n; i++ {
+			taskId := f.addTask(t, fmt.Sprint("task ", i), "")
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = f.tasks.StartTask(ctx, user.ID, taskId, at(1, 9, i), "", nil)
+			}(i)
+		}
+		wg.Wait()
+		for _, err := range errs {
+			noErr(t, "StartTask", err)
+		}
+
+		running, err := f.tasks.GetRunningWorklogs(ctx, user.ID)
+		noErr(t, "GetRunningWorklogs", err)
+		if len(running) != n {
+			t.Fatalf("%d timers are running, want %d", len(running), n)
+		}
+		for i := 1; i < n; i++ {
+			if running[i].StartTime.Before(running[i-1].StartTime) {
+				t.Fatalf("GetRunningWorklogs isn't ordered by start time: %+v", running)
+			}
+		}
+	})
+
+	t.Run("Worklogs", func(t *testing.T) {
+		f := newFixture(t)
+		user := createUser(t, f, "1234 567890", "Ivanov", "Ivan")
+		other := createUser(t, f, "1111 111111", "Petrov", "Petr")
+		invoices := f.addTask(t, "Invoices", "March invoices")
+		review := f.addTask(t, "Review", "")
+
+		track := func(userId, taskId int, start, end time.Time) {
+			t.Helper()
+			noErr(t, "StartTask", f.tasks.StartTask(ctx, userId, taskId, start, "", nil))
+			noErr(t, "StopTask", f.tasks.StopTask(ctx, userId, taskId, end, ""))
+		}
+		track(user.ID, invoices, at(5, 10, 0), at(5, 12, 30))
+		track(user.ID, invoices, at(6, 10, 0), at(6, 10, 45))
+		track(user.ID, review, at(7, 10, 0), at(7, 11, 0))
+		track(user.ID, review, at(20, 10, 0), at(20, 15, 0))
+		track(other.ID, review, at(5, 10, 0), at(5, 18, 0))
+		// Незавершённый таймер не учитывается
+		noErr(t, "StartTask", f.tasks.StartTask(ctx, user.ID, review, at(8, 10, 0), "", nil))
+
+		tasks, err := f.tasks.GetWorklogs(ctx, user.ID, "2024-03-01", "2024-03-10", nil)
+		noErr(t, "GetWorklogs", err)
+		want := []models.Task{
+			{ID: invoices, Name: "Invoices", Description: "March invoices", TotalHours: 3, TotalMinutes: 15},
+			{ID: review, Name: "Review", TotalHours: 1, TotalMinutes: 0},
+		}
+		if fmt.Sprint(tasks) != fmt.Sprint(want) {
+			t.Fatalf("GetWorklogs = %+v, want %+v", tasks, want)
+		}
+
+		tasks, err = f.tasks.GetWorklogs(ctx, user.ID, "-infinity", "infinity", nil)
+		noErr(t, "GetWorklogs", err)
+		want = []models.Task{
+			{ID: review, Name: "Review", TotalHours: 6, TotalMinutes: 0},
+			{ID: invoices, Name: "Invoices", Description: "March invoices", TotalHours: 3, TotalMinutes: 15},
+		}
+		if fmt.Sprint(tasks) != fmt.Sprint(want) {
+			t.Fatalf("GetWorklogs of all time = %+v, want %+v", tasks, want)
+		}
+
+		tasks, err = f.tasks.GetWorklogs(ctx, user.ID, "2024-04-01", "infinity", nil)
+		noErr(t, "GetWorklogs", err)
+		if len(tasks) != 0 {
+			t.Fatalf("GetWorklogs of an empty period = %+v", tasks)
+		}
+	})
+
+	t.Run("AutoStop", func(t *testing.T) {
+		f := newFixture(t)
+		user := createUser(t, f, "1234 567890", "Ivanov", "Ivan")
+		teammate := createUser(t, f, "1111 111111", "Petrov", "Petr")
+		loner := createUser(t, f, "2222 222222", "Sidorov", "Sidor")
+		taskId := f.addTask(t, "Invoices", "")
+		for _, u := range []*models.User{user, teammate} {
+			u.Team = "core"
+			noErr(t, "UpdateUser", f.users.UpdateUser(ctx, u))
+		}
+
+		teamPolicy := &models.AutoStopPolicy{Team: "core", MaxDuration: models.Duration(8 * time.Hour), StopAt: models.StopAtCap, Cap: models.Duration(time.Hour)}
+		noErr(t, "SavePolicy", f.policies.SavePolicy(ctx, teamPolicy))
+		userPolicy := &models.AutoStopPolicy{UserID: &user.ID, MaxDuration: models.Duration(2 * time.Hour), StopAt: models.StopAtLastActivity, Cap: models.Duration(time.Hour)}
+		noErr(t, "SavePolicy", f.policies.SavePolicy(ctx, userPolicy))
+
+		noErr(t, "StartTask", f.tasks.StartTask(ctx, user.ID, taskId, at(1, 9, 0), "", nil))
+		noErr(t, "StartTask", f.tasks.StartTask(ctx, teammate.ID, taskId, at(1, 10, 0), "", nil))
+		noErr(t, "StartTask", f.tasks.StartTask(ctx, loner.ID, taskId, at(1, 11, 0), "", nil))
+
+		open, err := f.tasks.GetOpenWorklogs(ctx)
+		noErr(t, "GetOpenWorklogs", err)
+		if len(open) != 3 {
+			t.Fatalf("GetOpenWorklogs returned %d worklogs, want 3", len(open))
+		}
+		if open[0].UserID != user.ID || open[0].Policy == nil || open[0].Policy.ID != userPolicy.ID {
+			t.Fatalf("timer of a user with a policy got %+v", open[0].Policy)
+		}
+		if open[1].UserID != teammate.ID || open[1].Policy == nil || open[1].Policy.ID != teamPolicy.ID {
+			t.Fatalf("timer of a team member got %+v", open[1].Policy)
+		}
+		if open[2].UserID != loner.ID || open[2].Policy != nil {
+			t.Fatalf("timer of a user without policy got %+v", open[2].Policy)
+		}
+
+		noErr(t, "AutoStopWorklog", f.tasks.AutoStopWorklog(ctx, open[0].ID, at(1, 11, 0)))
+		wantErr(t, "AutoStopWorklog of a stopped timer", f.tasks.AutoStopWorklog(ctx, open[0].ID, at(1, 12, 0)), ErrNoRunningTask)
+		worklog, err := f.tasks.GetWorklog(ctx, user.ID, open[0].ID)
+		noErr(t, "GetWorklog", err)
+		if !worklog.AutoStopped || worklog.EndTime == nil || !worklog.EndTime.Equal(at(1, 11, 0)) {
+			t.Fatalf("GetWorklog after AutoStopWorklog = %+v", worklog)
+		}
+	})
+
+	t.Run("Idle", func(t *testing.T) {
+		f := newFixture(t)
+		user := createUser(t, f, "1234 567890", "Ivanov", "Ivan")
+		taskId := f.addTask(t, "Invoices", "")
+
+		_, err := f.tasks.RecordActivity(ctx, user.ID, taskId, at(1, 9, 0))
+		wantErr(t, "RecordActivity without a timer", err, ErrNoRunningTask)
+
+		noErr(t, "StartTask", f.tasks.StartTask(ctx, user.ID, taskId, at(1, 9, 0), "", nil))
+		worklog, err := f.tasks.RecordActivity(ctx, user.ID, taskId, at(1, 10, 0))
+		noErr(t, "RecordActivity", err)
+		worklog, err = f.tasks.RecordActivity(ctx, user.ID, taskId, at(1, 9, 30))
+		noErr(t, "RecordActivity", err)
+		if !worklog.LastActivityAt.Equal(at(1, 10, 0)) {
+			t.Fatalf("RecordActivity moved the last activity back to %v", worklog.LastActivityAt)
+		}
+
+		// Простой с 10:00 предлагается пользователю
+		flagged, err := f.tasks.DetectIdle(ctx, at(1, 10, 15), false)
+		noErr(t, "DetectIdle", err)
+		if len(flagged) != 1 || flagged[0].IdleStatus != models.IdleProposed || !flagged[0].IdleSince.Equal(at(1, 10, 0)) || flagged[0].EndTime != nil {
+			t.Fatalf("DetectIdle = %+v", flagged)
+		}
+		if count, err := f.tasks.CountPendingIdle(ctx); err != nil || count != 1 {
+			t.Fatalf("CountPendingIdle = %d, %v; want 1", count, err)
+		}
+		flagged, err = f.tasks.DetectIdle(ctx, at(1, 10, 30), false)
+		noErr(t, "DetectIdle", err)
+		if len(flagged) != 0 {
+			t.Fatalf("DetectIdle flagged a proposed worklog again: %+v", flagged)
+		}
+
+		resolved, err := f.tasks.ResolveIdle(ctx, user.ID, worklog.ID, false, nil)
+		noErr(t, "ResolveIdle", err)
+		if resolved.IdleStatus != models.IdleDiscarded || resolved.EndTime != nil {
+			t.Fatalf("ResolveIdle(discard) = %+v", resolved)
+		}
+		_, err = f.tasks.ResolveIdle(ctx, user.ID, worklog.ID, true, nil)
+		wantErr(t, "ResolveIdle without a proposal", err, ErrNoIdleProposal)
+
+		// Отклонённый простой снова предлагается только после новой активности
+		flagged, err = f.tasks.DetectIdle(ctx, at(1, 12, 0), false)
+		noErr(t, "DetectIdle", err)
+		if len(flagged) != 0 {
+			t.Fatalf("DetectIdle flagged a discarded worklog without new activity: %+v", flagged)
+		}
+		_, err = f.tasks.RecordActivity(ctx, user.ID, taskId, at(1, 11, 0))
+		noErr(t, "RecordActivity", err)
+		flagged, err = f.tasks.DetectIdle(ctx, at(1, 12, 0), false)
+		noErr(t, "DetectIdle", err)
+		if len(flagged) != 1 || !flagged[0].IdleSince.Equal(at(1, 11, 0)) {
+			t.Fatalf("DetectIdle after new activity = %+v", flagged)
+		}
+
+		resolved, err = f.tasks.ResolveIdle(ctx, user.ID, worklog.ID, true, nil)
+		noErr(t, "ResolveIdle", err)
+		if resolved.IdleStatus != models.IdleAccepted || resolved.EndTime == nil || !resolved.EndTime.Equal(at(1, 11, 0)) {
+			t.Fatalf("ResolveIdle(accept) = %+v", resolved)
+		}
+		if count, err := f.tasks.CountPendingIdle(ctx); err != nil || count != 0 {
+			t.Fatalf("CountPendingIdle = %d, %v; want 0", count, err)
+		}
+	})
+
+	t.Run("IdleActivity", func(t *testing.T) {
+		f := newFixture(t)
+		user := createUser(t, f, "1234 567890", "Ivanov", "Ivan")
+		taskId := f.addTask(t, "Invoices", "")
+
+		noErr(t, "StartTask", f.tasks.StartTask(ctx, user.ID, taskId, at(1, 9, 0), "", nil))
+		flagged, err := f.tasks.DetectIdle(ctx, at(1, 9, 30), false)
+		noErr(t, "DetectIdle", err)
+		if len(flagged) != 1 {
+			t.Fatalf("DetectIdle = %+v", flagged)
+		}
+
+		// Запоздавшая активность до начала простоя предложение не снимает
+		worklog, err := f.tasks.RecordActivity(ctx, user.ID, taskId, at(1, 9, 0))
+		noErr(t, "RecordActivity", err)
+		if worklog.IdleStatus != models.IdleProposed {
+			t.Fatalf("RecordActivity before the idle tail = %+v", worklog)
+		}
+
+		// Новая активность снимает предложение
+		worklog, err = f.tasks.RecordActivity(ctx, user.ID, taskId, at(1, 9, 45))
+		noErr(t, "RecordActivity", err)
+		if worklog.IdleStatus != "" || worklog.IdleSince != nil {
+			t.Fatalf("RecordActivity after the idle tail = %+v", worklog)
+		}
+		if count, err := f.tasks.CountPendingIdle(ctx); err != nil || count != 0 {
+			t.Fatalf("CountPendingIdle = %d, %v; want 0", count, err)
+		}
+		_, err = f.tasks.ResolveIdle(ctx, user.ID, worklog.ID, true, nil)
+		wantErr(t, "ResolveIdle of a withdrawn proposal", err, ErrNoIdleProposal)
+
+		// Следующий простой предлагается заново
+		flagged, err = f.tasks.DetectIdle(ctx, at(1, 10, 30), false)
+		noErr(t, "DetectIdle", err)
+		if len(flagged) != 1 || !flagged[0].IdleSince.Equal(at(1, 9, 45)) {
+			t.Fatalf("DetectIdle after new activity = %+v", flagged)
+		}
+
+		// Остановленный до начала простоя таймер не удлиняется
+		noErr(t, "StopTask", f.tasks.StopTask(ctx, user.ID, taskId, at(1, 9, 40), ""))
+		_, err = f.tasks.ResolveIdle(ctx, user.ID, worklog.ID, true, nil)
+		wantErr(t, "ResolveIdle(accept) of a worklog stopped before the idle tail", err, ErrNoIdleProposal)
+		resolved, err := f.tasks.ResolveIdle(ctx, user.ID, worklog.ID, false, nil)
+		noErr(t, "ResolveIdle", err)
+		if resolved.IdleStatus != models.IdleDiscarded || !resolved.EndTime.Equal(at(1, 9, 40)) {
+			t.Fatalf("ResolveIdle(discard) = %+v", resolved)
+		}
+	})
+
+	t.Run("IdleResume", func(t *testing.T) {
+		f := newFixture(t)
+		user := createUser(t, f, "1234 567890", "Ivanov", "Ivan")
+		taskId := f.addTask(t, "Invoices", "")
+		review := createTag(t, f, user.ID, "review")
+
+		noErr(t, "StartTask", f.tasks.StartTask(ctx, user.ID, taskId, at(1, 9, 0), "invoices", []int{review.ID}))
+		flagged, err := f.tasks.DetectIdle(ctx, at(1, 9, 30), false)
+		noErr(t, "DetectIdle", err)
+		if len(flagged) != 1 {
+			t.Fatalf("DetectIdle = %+v", flagged)
+		}
+		resumeAt := at(1, 10, 0)
+		_, err = f.tasks.ResolveIdle(ctx, user.ID, flagged[0].ID+100, true, &resumeAt)
+		wantErr(t, "ResolveIdle of a missing worklog", err, ErrWorklogNotFound)
+
+		// Обрезка и новый таймер с той же заметкой и тегами
+		resolved, err := f.tasks.ResolveIdle(ctx, user.ID, flagged[0].ID, true, &resumeAt)
+		noErr(t, "ResolveIdle", err)
+		if resolved.IdleStatus != models.IdleAccepted || !resolved.EndTime.Equal(at(1, 9, 0)) {
+			t.Fatalf("ResolveIdle(accept) = %+v", resolved)
+		}
+		running, err := f.tasks.GetRunningWorklogs(ctx, user.ID)
+		noErr(t, "GetRunningWorklogs", err)
+		if len(running) != 1 || running[0].ID == resolved.ID || !running[0].StartTime.Equal(resumeAt) ||
+			running[0].Note != "invoices" || namesOf(running[0].Tags) != "[review]" {
+			t.Fatalf("GetRunningWorklogs after resuming = %+v", running)
+		}
+	})
+
+	t.Run("IdleApply", func(t *testing.T) {
+		f := newFixture(t)
+		user := createUser(t, f, "1234 567890", "Ivanov", "Ivan")
+		taskId := f.addTask(t, "Invoices", "")
+
+		noErr(t, "StartTask", f.tasks.StartTask(ctx, user.ID, taskId, at(1, 9, 0), "", nil))
+		flagged, err := f.tasks.DetectIdle(ctx, at(1, 10, 0), true)
+		noErr(t, "DetectIdle", err)
+		if len(flagged) != 1 || flagged[0].IdleStatus != models.IdleApplied || flagged[0].EndTime == nil || !flagged[0].EndTime.Equal(at(1, 9, 0)) {
+			t.Fatalf("DetectIdle(apply) = %+v", flagged)
+		}
+		if count, err := f.tasks.CountOpenWorklogs(ctx); err != nil || count != 0 {
+			t.Fatalf("CountOpenWorklogs = %d, %v; want 0", count, err)
+		}
+	})
+}
+
+func testAutoStopPolicyRepository(t *testing.T, newFixture func(t *testing.T) fixture) {
+	ctx := context.Background()
+	f := newFixture(t)
+	user := createUser(t, f, "1234 567890", "Ivanov", "Ivan")
+	missing := user.ID + 100
+
+	err := f.policies.SavePolicy(ctx, &models.AutoStopPolicy{UserID: &missing, MaxDuration: models.Duration(time.Hour), StopAt: models.StopAtCap, Cap: models.Duration(time.Hour)})
+	wantErr(t, "SavePolicy of a missing user", err, ErrUserNotFound)
+
+	userPolicy := &models.AutoStopPolicy{UserID: &user.ID, MaxDuration: models.Duration(time.Hour), StopAt: models.StopAtCap, Cap: models.Duration(time.Hour)}
+	noErr(t, "SavePolicy", f.policies.SavePolicy(ctx, userPolicy))
+	teamPolicy := &models.AutoStopPolicy{Team: "core", MaxDuration: models.Duration(time.Hour), StopAt: models.StopAtCap, Cap: models.Duration(time.Hour)}
+	noErr(t, "SavePolicy", f.policies.SavePolicy(ctx, teamPolicy))
+
+	// Повторное сохранение заменяет политику
+	replaced := &models.AutoStopPolicy{UserID: &user.ID, MaxDuration: models.Duration(3 * time.Hour), StopAt: models.StopAtLastActivity, Cap: models.Duration(30 * time.Minute)}
+	noErr(t, "SavePolicy", f.policies.SavePolicy(ctx, replaced))
+	if replaced.ID != userPolicy.ID {
+		t.Fatalf("SavePolicy created policy %d instead of replacing %d", replaced.ID, userPolicy.ID)
+	}
+
+	policies, err := f.policies.GetPolicies(ctx)
+	noErr(t, "GetPolicies", err)
+	if len(policies) != 2 || policies[0].ID != userPolicy.ID || time.Duration(policies[0].MaxDuration) != 3*time.Hour ||
+		policies[0].StopAt != models.StopAtLastActivity || policies[1].Team != "core" || policies[1].UserID != nil {
+		t.Fatalf("GetPolicies = %+v", policies)
+	}
+
+	noErr(t, "DeleteUserPolicy", f.policies.DeleteUserPolicy(ctx, user.ID))
+	wantErr(t, "DeleteUserPolicy without a policy", f.policies.DeleteUserPolicy(ctx, user.ID), ErrPolicyNotFound)
+	noErr(t, "DeleteTeamPolicy", f.policies.DeleteTeamPolicy(ctx, "core"))
+	wantErr(t, "DeleteTeamPolicy without a policy", f.policies.DeleteTeamPolicy(ctx, "core"), ErrPolicyNotFound)
+}
+
+func createTag(t *testing.T, f fixture, userId int, name string) *models.Tag {
+	t.Helper()
+	tag := &models.Tag{UserID: userId, Name: name, Color: "#1e88e5"}
+	if err := f.tags.CreateTag(context.Background(), tag); err != nil {
+		t.Fatalf("CreateTag(%q): %v", name, err)
+	}
+	return tag
+}
+
+func namesOf(tags []models.Tag) string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return fmt.Sprint(names)
+}
+
+func testTagRepository(t *testing.T, newFixture func(t *testing.T) fixture) {
+	ctx := context.Background()
+
+	t.Run("CRUD", func(t *testing.T) {
+		f := newFixture(t)
+		user := createUser(t, f, "1234 567890", "Ivanov", "Ivan")
+		other := createUser(t, f, "1111 111111", "Petrov", "Petr")
+
+		review := createTag(t, f, user.ID, "review")
+		createTag(t, f, user.ID, "meeting")
+		createTag(t, f, other.ID, "review")
+		if review.ID == 0 {
+			t.Fatalf("CreateTag didn't set ID: %+v", review)
+		}
+		wantErr(t, "CreateTag with a taken name", f.tags.CreateTag(ctx, &models.Tag{UserID: user.ID, Name: "review", Color: "#000000"}), ErrTagExists)
+		wantErr(t, "CreateTag of a missing user", f.tags.CreateTag(ctx, &models.Tag{UserID: user.ID + 100, Name: "review", Color: "#000000"}), ErrUserNotFound)
+
+		tags, err := f.tags.GetTags(ctx, user.ID)
+		noErr(t, "GetTags", err)
+		if namesOf(tags) != "[meeting review]" || tags[1] != *review {
+			t.Fatalf("GetTags = %+v", tags)
+		}
+
+		renamed := &models.Tag{ID: review.ID, UserID: user.ID, Name: "code review", Color: "#ff0000"}
+		noErr(t, "UpdateTag", f.tags.UpdateTag(ctx, renamed))
+		wantErr(t, "UpdateTag to a taken name", f.tags.UpdateTag(ctx, &models.Tag{ID: review.ID, UserID: user.ID, Name: "meeting", Color: "#ff0000"}), ErrTagExists)
+		wantErr(t, "UpdateTag of another user", f.tags.UpdateTag(ctx, &models.Tag{ID: review.ID, UserID: other.ID, Name: "x", Color: "#ff0000"}), ErrTagNotFound)
+		tags, err = f.tags.GetTags(ctx, user.ID)
+		noErr(t, "GetTags", err)
+		if namesOf(tags) != "[code review meeting]" || tags[0].Color != "#ff0000" {
+			t.Fatalf("GetTags after UpdateTag = %+v", tags)
+		}
+
+		wantErr(t, "DeleteTag of another user", f.tags.DeleteTag(ctx, other.ID, review.ID), ErrTagNotFound)
+		noErr(t, "DeleteTag", f.tags.DeleteTag(ctx, user.ID, review.ID))
+		wantErr(t, "DeleteTag twice", f.tags.DeleteTag(ctx, user.ID, review.ID), ErrTagNotFound)
+		tags, err = f.tags.GetTags(ctx, user.ID)
+		noErr(t, "GetTags", err)
+		if namesOf(tags) != "[meeting]" {
+			t.Fatalf("GetTags after DeleteTag = %+v", tags)
+		}
+	})
+
+	t.Run("NotesAndWorklogTags", func(t *testing.T) {
+		f := newFixture(t)
+		user := createUser(t, f, "1234 567890", "Ivanov", "Ivan")
+		other := createUser(t, f, "1111 111111", "Petrov", "Petr")
+		taskId := f.addTask(t, "Invoices", "")
+		review := createTag(t, f, user.ID, "review")
+		meeting := createTag(t, f, user.ID, "meeting")
+		foreign := createTag(t, f, other.ID, "foreign")
+
+		err := f.tasks.StartTask(ctx, user.ID, taskId, at(1, 9, 0), "", []int{foreign.ID})
+		wantErr(t, "StartTask with a tag of another user", err, ErrTagNotFound)
+		if count, err := f.tasks.CountOpenWorklogs(ctx); err != nil || count != 0 {
+			t.Fatalf("StartTask with a bad tag left %d timers, %v", count, err)
+		}
+
+		noErr(t, "StartTask", f.tasks.StartTask(ctx, user.ID, taskId, at(1, 9, 0), "March invoices", []int{review.ID, meeting.ID, review.ID}))
+		running, err := f.tasks.GetRunningWorklogs(ctx, user.ID)
+		noErr(t, "GetRunningWorklogs", err)
+		if len(running) != 1 || running[0].Note != "March invoices" || namesOf(running[0].Tags) != "[meeting review]" {
+			t.Fatalf("GetRunningWorklogs = %+v", running)
+		}
+		worklogId := running[0].ID
+
+		noErr(t, "StopTask", f.tasks.StopTask(ctx, user.ID, taskId, at(1, 10, 0), "sent to the client"))
+		worklog, err := f.tasks.GetWorklog(ctx, user.ID, worklogId)
+		noErr(t, "GetWorklog", err)
+		if worklog.Note != "March invoices\nsent to the client" || namesOf(worklog.Tags) != "[meeting review]" {
+			t.Fatalf("GetWorklog = %+v", worklog)
+		}
+
+		tags, err := f.tags.SetWorklogTags(ctx, user.ID, worklogId, []int{meeting.ID})
+		noErr(t, "SetWorklogTags", err)
+		if namesOf(tags) != "[meeting]" {
+			t.Fatalf("SetWorklogTags = %+v", tags)
+		}
+		_, err = f.tags.SetWorklogTags(ctx, user.ID, worklogId, []int{review.ID, foreign.ID})
+		wantErr(t, "SetWorklogTags with a tag of another user", err, ErrTagNotFound)
+		_, err = f.tags.SetWorklogTags(ctx, other.ID, worklogId, nil)
+		wantErr(t, "SetWorklogTags of another user's worklog", err, ErrWorklogNotFound)
+
+		noErr(t, "DeleteTag", f.tags.DeleteTag(ctx, user.ID, meeting.ID))
+		worklog, err = f.tasks.GetWorklog(ctx, user.ID, worklogId)
+		noErr(t, "GetWorklog", err)
+		if len(worklog.Tags) != 0 {
+			t.Fatalf("deleted tag is still on the worklog: %+v", worklog.Tags)
+		}
+
+		// Заметка без начальной и пустая заметка при остановке
+		noErr(t, "StartTask", f.tasks.StartTask(ctx, user.ID, taskId, at(2, 9, 0), "", nil))
+		noErr(t, "StopTask", f.tasks.StopTask(ctx, user.ID, taskId, at(2, 10, 0), "late note"))
+		noErr(t, "StartTask", f.tasks.StartTask(ctx, user.ID, taskId, at(3, 9, 0), "early note", nil))
+		noErr(t, "StopTask", f.tasks.StopTask(ctx, user.ID, taskId, at(3, 10, 0), ""))
+		for id, want := range map[int]string{worklogId + 1: "late note", worklogId + 2: "early note"} {
+			worklog, err := f.tasks.GetWorklog(ctx, user.ID, id)
+			noErr(t, "GetWorklog", err)
+			if worklog.Note != want {
+				t.Fatalf("worklog %d has note %q, want %q", id, worklog.Note, want)
+			}
+		}
+	})
+
+	t.Run("Reports", func(t *testing.T) {
+		f := newFixture(t)
+		user := createUser(t, f, "1234 567890", "Ivanov", "Ivan")
+		other := createUser(t, f, "1111 111111", "Petrov", "Petr")
+		invoices := f.addTask(t, "Invoices", "")
+		review := f.addTask(t, "Review", "")
+		billing := createTag(t, f, user.ID, "billing")
+		meeting := createTag(t, f, user.ID, "meeting")
+		reviewing := createTag(t, f, user.ID, "reviewing")
+		otherBilling := createTag(t, f, other.ID, "billing")
+
+		track := func(userId, taskId int, start, end time.Time, tagIds ...int) {
+			t.Helper()
+			noErr(t, "StartTask", f.tasks.StartTask(ctx, userId, taskId, start, "", tagIds))
+			noErr(t, "StopTask", f.tasks.StopTask(ctx, userId, taskId, end, ""))
+		}
+		track(user.ID, invoices, at(5, 10, 0), at(5, 12, 30), meeting.ID)
+		track(user.ID, invoices, at(6, 10, 0), at(6, 10, 45))
+		track(user.ID, review, at(7, 10, 0), at(7, 11, 0), reviewing.ID)
+		track(user.ID, review, at(8, 10, 0), at(8, 10, 20))
+		track(other.ID, review, at(5, 10, 0), at(5, 18, 0))
+
+		_, err := f.tags.SetTaskTags(ctx, user.ID, invoices+100, []int{billing.ID})
+		wantErr(t, "SetTaskTags of a missing task", err, ErrTaskNotFound)
+		_, err = f.tags.SetTaskTags(ctx, user.ID, invoices, []int{otherBilling.ID})
+		wantErr(t, "SetTaskTags with a tag of another user", err, ErrTagNotFound)
+		// Теги другого пользователя на задаче не влияют на отчёты этого
+		_, err = f.tags.SetTaskTags(ctx, other.ID, review, []int{otherBilling.ID})
+		noErr(t, "SetTaskTags", err)
+		tags, err := f.tags.SetTaskTags(ctx, user.ID, invoices, []int{billing.ID})
+		noErr(t, "SetTaskTags", err)
+		if namesOf(tags) != "[billing]" {
+			t.Fatalf("SetTaskTags = %+v", tags)
+		}
+
+		report := func(tags ...string) string {
+			t.Helper()
+			tasks, err := f.tasks.GetWorklogs(ctx, user.ID, "-infinity", "infinity", tags)
+			noErr(t, "GetWorklogs", err)
+			var rows []string
+			for _, task := range tasks {
+				rows = append(rows, fmt.Sprintf("%s %d:%02d", task.Name, task.TotalHours, task.TotalMinutes))
+			}
+			return fmt.Sprint(rows)
+		}
+		for _, tc := range []struct {
+			tags []string
+			want string
+		}{
+			{nil, "[Invoices 3:15 Review 1:20]"},
+			{[]string{"billing"}, "[Invoices 3:15]"},
+			{[]string{"reviewing", "meeting"}, "[Invoices 2:30 Review 1:00]"},
+			{[]string{"nothing"}, "[]"},
+		} {
+			if got := report(tc.tags...); got != tc.want {
+				t.Fatalf("GetWorklogs with tags %v = %s, want %s", tc.tags, got, tc.want)
+			}
+		}
+
+		totals := func(tags ...string) string {
+			t.Helper()
+			totals, err := f.tasks.GetTagTotals(ctx, user.ID, "-infinity", "infinity", tags)
+			noErr(t, "GetTagTotals", err)
+			var rows []string
+			for _, total := range totals {
+				name := "-"
+				if total.Tag != nil {
+					name = total.Tag.Name
+				}
+				rows = append(rows, fmt.Sprintf("%s %d:%02d", name, total.TotalHours, total.TotalMinutes))
+			}
+			return fmt.Sprint(rows)
+		}
+		for _, tc := range []struct {
+			tags []string
+			want string
+		}{
+			{nil, "[billing 3:15 meeting 2:30 reviewing 1:00 - 0:20]"},
+			{[]string{"meeting"}, "[billing 2:30 meeting 2:30]"},
+			{[]string{"nothing"}, "[]"},
+		} {
+			if got := totals(tc.tags...); got != tc.want {
+				t.Fatalf("GetTagTotals with tags %v = %s, want %s", tc.tags, got, tc.want)
+			}
+		}
+
+		// Время без тегов идёт последним среди равных
+		track(user.ID, review, at(9, 10, 0), at(9, 10, 40))
+		if got, want := totals(), "[billing 3:15 meeting 2:30 reviewing 1:00 - 1:00]"; got != want {
+			t.Fatalf("GetTagTotals = %s, want %s", got, want)
+		}
+	})
+}
+
+// testSearchRepository only uses words that Postgres neither stems
+// differently nor drops, which the in-memory search can't do.
+func testSearchRepository(t *testing.T, newFixture func(t *testing.T) fixture) {
+	ctx := context.Background()
+	f := newFixture(t)
+	user := createUser(t, f, "1234 567890", "Ivanov", "Ivan")
+	other := createUser(t, f, "1111 111111", "Petrov", "Petr")
+	invoices := f.addTask(t, "Invoices", "Monthly billing for clients")
+	f.addTask(t, "Code review", "Review the invoices module")
+	standup := f.addTask(t, "Standup", "")
+
+	track := func(userId, taskId int, start time.Time, note string) {
+		t.Helper()
+		noErr(t, "StartTask", f.tasks.StartTask(ctx, userId, taskId, start, note, nil))
+		noErr(t, "StopTask", f.tasks.StopTask(ctx, userId, taskId, start.Add(15*time.Minute), ""))
+	}
+	track(user.ID, standup, at(4, 9, 0), "talked over invoices")
+	track(user.ID, standup, at(5, 9, 0), "nothing to report")
+	track(user.ID, invoices, at(12, 10, 0), "March invoices <draft>")
+	track(other.ID, standup, at(20, 9, 0), "invoices again")
+
+	search := func(params models.SearchParams) ([]models.SearchHit, string) {
+		t.Helper()
+		if params.Limit == 0 {
+			params.Limit = 10
+		}
+		hits, err := f.search.Search(ctx, params)
+		noErr(t, "Search", err)
+		var rows []string
+		for _, hit := range hits {
+			row := hit.Type + " " + hit.TaskName
+			if hit.Type == models.SearchHitWorklog {
+				row += fmt.Sprintf(" %d", hit.StartTime.Day())
+			}
+			rows = append(rows, row)
+		}
+		return hits, fmt.Sprint(rows)
+	}
+
+	from, to := at(10, 0, 0), at(15, 0, 0)
+	for _, tc := range []struct {
+		name   string
+		params models.SearchParams
+		want   string
+	}{
+		{"all", models.SearchParams{Query: "invoices"},
+			"[task Invoices task Code review worklog Standup 20 worklog Invoices 12 worklog Standup 4]"},
+		{"description", models.SearchParams{Query: "billing"}, "[task Invoices]"},
+		{"every word", models.SearchParams{Query: "invoices March"}, "[worklog Invoices 12]"},
+		{"user", models.SearchParams{Query: "invoices", UserID: user.ID},
+			"[task Invoices worklog Invoices 12 worklog Standup 4]"},
+		{"period", models.SearchParams{Query: "invoices", From: &from, To: &to}, "[task Invoices worklog Invoices 12]"},
+		{"user and period", models.SearchParams{Query: "invoices", UserID: other.ID, From: &from}, "[worklog Standup 20]"},
+		{"limit", models.SearchParams{Query: "invoices", Limit: 2}, "[task Invoices task Code review]"},
+		{"no match", models.SearchParams{Query: "payroll"}, "[]"},
+		{"no stemming", models.SearchParams{Query: "invoice"}, "[]"},
+		{"no stop words", models.SearchParams{Query: "over"}, "[worklog Standup 4]"},
+	} {
+		if _, got := search(tc.params); got != tc.want {
+			t.Fatalf("Search %s = %s, want %s", tc.name, got, tc.want)
+		}
+	}
+
+	// Совпадение в названии весит больше, чем в описании и заметках
+	hits, _ := search(models.SearchParams{Query: "invoices", UserID: user.ID, From: &from})
+	if hits[0].Rank != 0.6079 || hits[1].Rank != 0.2432 {
+		t.Fatalf("Search ranks = %v, %v", hits[0].Rank, hits[1].Rank)
+	}
+	hit := hits[1]
+	if hit.UserID != user.ID || hit.WorklogID == 0 || !hit.StartTime.Equal(at(12, 10, 0)) || !hit.EndTime.Equal(at(12, 10, 15)) {
+		t.Fatalf("worklog hit = %+v", hit)
+	}
+	if !strings.Contains(hit.Snippet, "<b>invoices</b>") || strings.Contains(hit.Snippet, "<draft>") {
+		t.Fatalf("worklog hit snippet = %q", hit.Snippet)
+	}
+	if !strings.Contains(hits[0].Snippet, "<b>Invoices</b>") {
+		t.Fatalf("task hit snippet = %q", hits[0].Snippet)
+	}
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrPassportExists  = errors.New("passport number already exists")
+	ErrTaskNotFound    = errors.New("user or task not found")
+	ErrNoRunningTask   = errors.New("no running task")
+	ErrWorklogNotFound = errors.New("worklog not found")
+	ErrNoIdleProposal  = errors.New("no pending idle proposal")
+)
+
+// Коды ошибок PostgreSQL, см. https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pqUniqueViolation     = "23505"
+	pqForeignKeyViolation = "23503"
+	pqQueryCanceled       = "57014"
+)
+
+const passportUniqueConstraint = "users_passport_number_key"
+
+func isPQError(err error, code pq.ErrorCode) (*pq.Error, bool) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == code {
+		return pqErr, true
+	}
+	return nil, false
+}
+
+// mapUserWriteError translates constraint violations on the users table into
+// repository errors.
+func mapUserWriteError(err error) error {
+	if pqErr, ok := isPQError(err, pqUniqueViolation); ok && pqErr.Constraint == passportUniqueConstraint {
+		return ErrPassportExists
+	}
+	return err
+}
+
+// IsTimeout reports whether err means a statement was cancelled because its
+// context expired, either before it reached the server or while running.
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	_, ok := isPQError(err, pqQueryCanceled)
+	return ok
+}
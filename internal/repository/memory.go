@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+)
+
+// MemoryStore holds users, tasks, worklogs, tags and auto-stop policies in
+// memory. The in-memory repositories built on it follow the semantics of the
+// Postgres ones, including their constraints, and are safe for concurrent
+// use. They are meant for tests and local experiments.
+type MemoryStore struct {
+	mu       sync.Mutex
+	users    map[int]*memoryUser
+	tasks    map[int]memoryTask
+	worklogs map[int]*models.Worklog
+	policies map[int]*models.AutoStopPolicy
+	tags     map[int]*models.Tag
+	// worklogTags and taskTags hold the tag IDs of worklogs and tasks.
+	worklogTags map[int][]int
+	taskTags    map[int][]int
+	// lastID holds the last generated ID per table, like the SERIAL sequences.
+	lastID map[string]int
+}
+
+type memoryUser struct {
+	models.User
+	purged bool
+}
+
+type memoryTask struct {
+	name        string
+	description string
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		users:       make(map[int]*memoryUser),
+		tasks:       make(map[int]memoryTask),
+		worklogs:    make(map[int]*models.Worklog),
+		policies:    make(map[int]*models.AutoStopPolicy),
+		tags:        make(map[int]*models.Tag),
+		worklogTags: make(map[int][]int),
+		taskTags:    make(map[int][]int),
+		lastID:      make(map[string]int),
+	}
+}
+
+// AddTask creates a task and returns its ID. There is no repository method
+// for that, tasks are created directly in the database.
+func (s *MemoryStore) AddTask(name, description string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextID("tasks")
+	s.tasks[id] = memoryTask{name: name, description: description}
+	return id
+}
+
+// lock takes the store lock unless ctx is already done, the way a query with
+// a cancelled context never runs.
+func (s *MemoryStore) lock(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	return nil
+}
+
+func (s *MemoryStore) nextID(table string) int {
+	s.lastID[table]++
+	return s.lastID[table]
+}
+
+// sortedIDs returns the keys of a table in ascending order.
+func sortedIDs[T any](table map[int]T) []int {
+	ids := make([]int, 0, len(table))
+	for id := range table {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// dbTime rounds t to microseconds, the precision of a Postgres timestamp.
+func dbTime(t time.Time) time.Time {
+	return t.Round(time.Microsecond)
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+)
+
+type memoryAutoStopPolicyRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryAutoStopPolicyRepository(store *MemoryStore) AutoStopPolicyRepository {
+	return &memoryAutoStopPolicyRepository{store: store}
+}
+
+func (r *memoryAutoStopPolicyRepository) GetPolicies(ctx context.Context) ([]models.AutoStopPolicy, error) {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	var policies []models.AutoStopPolicy
+	for _, id := range sortedIDs(s.policies) {
+		policies = append(policies, copyPolicy(s.policies[id]))
+	}
+	return policies, nil
+}
+
+func (r *memoryAutoStopPolicyRepository) SavePolicy(ctx context.Context, policy *models.AutoStopPolicy) error {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	if policy.UserID != nil {
+		if _, ok := s.users[*policy.UserID]; !ok {
+			return ErrUserNotFound
+		}
+	}
+
+	for _, p := range s.policies {
+		if (policy.UserID != nil && p.UserID != nil && *p.UserID == *policy.UserID) ||
+			(policy.UserID == nil && p.UserID == nil && p.Team == policy.Team) {
+			p.MaxDuration, p.StopAt, p.Cap = policy.MaxDuration, policy.StopAt, policy.Cap
+			policy.ID = p.ID
+			return nil
+		}
+	}
+
+	saved := copyPolicy(policy)
+	saved.ID = s.nextID("auto_stop_policies")
+	s.policies[saved.ID] = &saved
+	policy.ID = saved.ID
+	return nil
+}
+
+func (r *memoryAutoStopPolicyRepository) DeleteUserPolicy(ctx context.Context, userId int) error {
+	return r.delete(ctx, func(p *models.AutoStopPolicy) bool {
+		return p.UserID != nil && *p.UserID == userId
+	})
+}
+
+func (r *memoryAutoStopPolicyRepository) DeleteTeamPolicy(ctx context.Context, team string) error {
+	return r.delete(ctx, func(p *models.AutoStopPolicy) bool {
+		return p.UserID == nil && p.Team == team
+	})
+}
+
+func (r *memoryAutoStopPolicyRepository) delete(ctx context.Context, match func(p *models.AutoStopPolicy) bool) error {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	for id, p := range s.policies {
+		if match(p) {
+			delete(s.policies, id)
+			return nil
+		}
+	}
+	return ErrPolicyNotFound
+}
+
+func copyPolicy(p *models.AutoStopPolicy) models.AutoStopPolicy {
+	c := *p
+	if p.UserID != nil {
+		userId := *p.UserID
+		c.UserID = &userId
+	}
+	return c
+}
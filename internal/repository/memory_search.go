@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+)
+
+// Веса ts_rank по умолчанию для весов A и B
+const (
+	searchWeightName = 1.0
+	searchWeightText = 0.4
+)
+
+// memorySearchRepository matches whole words case-insensitively, like the
+// simple configuration of Postgres, and requires every word of the query;
+// operators and phrases aren't supported. Ranks are those of ts_rank for
+// one-word queries, and snippets hold the whole text instead of fragments.
+type memorySearchRepository struct {
+	store *MemoryStore
+}
+
+func NewMemorySearchRepository(store *MemoryStore) SearchRepository {
+	return &memorySearchRepository{store: store}
+}
+
+func (r *memorySearchRepository) Search(ctx context.Context, params models.SearchParams) ([]models.SearchHit, error) {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	terms := searchWords(params.Query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	matches := func(w *models.Worklog) bool {
+		return (params.UserID == 0 || w.UserID == params.UserID) &&
+			(params.From == nil || !w.StartTime.Before(*params.From)) &&
+			(params.To == nil || w.StartTime.Before(*params.To))
+	}
+	filtered := params.UserID != 0 || params.From != nil || params.To != nil
+
+	var hits []models.SearchHit
+	for _, id := range sortedIDs(s.tasks) {
+		task := s.tasks[id]
+		rank, ok := searchRank(terms, searchField{task.name, searchWeightName}, searchField{task.description, searchWeightText})
+		if !ok || filtered && !s.anyWorklog(id, matches) {
+			continue
+		}
+		text := task.name
+		if task.description != "" {
+			text += ": " + task.description
+		}
+		hits = append(hits, models.SearchHit{
+			Type:     models.SearchHitTask,
+			TaskID:   id,
+			TaskName: task.name,
+			Rank:     rank,
+			Snippet:  highlight(text, terms),
+		})
+	}
+	for _, id := range sortedIDs(s.worklogs) {
+		w := s.worklogs[id]
+		rank, ok := searchRank(terms, searchField{w.Note, searchWeightText})
+		if !ok || !matches(w) {
+			continue
+		}
+		startTime := w.StartTime
+		hit := models.SearchHit{
+			Type:      models.SearchHitWorklog,
+			TaskID:    w.TaskID,
+			TaskName:  s.tasks[w.TaskID].name,
+			WorklogID: w.ID,
+			UserID:    w.UserID,
+			StartTime: &startTime,
+			Rank:      rank,
+			Snippet:   highlight(w.Note, terms),
+		}
+		if w.EndTime != nil {
+			endTime := *w.EndTime
+			hit.EndTime = &endTime
+		}
+		hits = append(hits, hit)
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return hitID(a) > hitID(b)
+	})
+	if params.Limit > 0 && len(hits) > params.Limit {
+		hits = hits[:params.Limit]
+	}
+	for i := range hits {
+		hits[i].Rank = math.Round(hits[i].Rank*1e4) / 1e4
+	}
+	return hits, nil
+}
+
+// anyWorklog reports whether a worklog of the task matches. The caller must
+// hold the lock.
+func (s *MemoryStore) anyWorklog(taskId int, matches func(w *models.Worklog) bool) bool {
+	for _, w := range s.worklogs {
+		if w.TaskID == taskId && matches(w) {
+			return true
+		}
+	}
+	return false
+}
+
+func hitID(hit models.SearchHit) int {
+	if hit.Type == models.SearchHitWorklog {
+		return hit.WorklogID
+	}
+	return hit.TaskID
+}
+
+type searchField struct {
+	text   string
+	weight float64
+}
+
+// searchRank ranks the fields against every term the way ts_rank ranks a
+// single lexeme: the occurrences count less and less the later they come.
+// It reports false unless every term occurs.
+func searchRank(terms []string, fields ...searchField) (float64, bool) {
+	var total float64
+	for _, term := range terms {
+		var weights []float64
+		for _, f := range fields {
+			for _, word := range searchWords(f.text) {
+				if word == term {
+					weights = append(weights, f.weight)
+				}
+			}
+		}
+		if len(weights) == 0 {
+			return 0, false
+		}
+
+		var sum, best float64
+		bestAt := 0
+		for j, w := range weights {
+			sum += w / float64((j+1)*(j+1))
+			if w > best {
+				best, bestAt = w, j
+			}
+		}
+		// Сумма 1/i^2 стремится к pi^2/6
+		total += (best + sum - best/float64((bestAt+1)*(bestAt+1))) / (math.Pi * math.Pi / 6)
+	}
+	return total / float64(len(terms)), true
+}
+
+// searchWords splits text into lower case words.
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !isWordRune(r)
+	})
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+var htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// highlight escapes text for HTML and puts the words that are terms in <b>
+// tags.
+func highlight(text string, terms []string) string {
+	var b strings.Builder
+	writeWord := func(word string) {
+		for _, term := range terms {
+			if strings.ToLower(word) == term {
+				b.WriteString("<b>" + htmlEscaper.Replace(word) + "</b>")
+				return
+			}
+		}
+		b.WriteString(htmlEscaper.Replace(word))
+	}
+
+	start := -1
+	for i, r := range text {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			writeWord(text[start:i])
+			start = -1
+		}
+		b.WriteString(htmlEscaper.Replace(string(r)))
+	}
+	if start >= 0 {
+		writeWord(text[start:])
+	}
+	return b.String()
+}
@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+)
+
+type memoryTagRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryTagRepository(store *MemoryStore) TagRepository {
+	return &memoryTagRepository{store: store}
+}
+
+func (r *memoryTagRepository) GetTags(ctx context.Context, userId int) ([]models.Tag, error) {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	var ids []int
+	for id, tag := range s.tags {
+		if tag.UserID == userId {
+			ids = append(ids, id)
+		}
+	}
+	return s.tagList(ids), nil
+}
+
+func (r *memoryTagRepository) CreateTag(ctx context.Context, tag *models.Tag) error {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[tag.UserID]; !ok {
+		return ErrUserNotFound
+	}
+	if s.tagNameTaken(tag.UserID, tag.Name, 0) {
+		return ErrTagExists
+	}
+
+	tag.ID = s.nextID("tags")
+	saved := *tag
+	s.tags[saved.ID] = &saved
+	return nil
+}
+
+func (r *memoryTagRepository) UpdateTag(ctx context.Context, tag *models.Tag) error {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	saved, ok := s.tags[tag.ID]
+	if !ok || saved.UserID != tag.UserID {
+		return ErrTagNotFound
+	}
+	if s.tagNameTaken(tag.UserID, tag.Name, tag.ID) {
+		return ErrTagExists
+	}
+	saved.Name, saved.Color = tag.Name, tag.Color
+	return nil
+}
+
+func (r *memoryTagRepository) DeleteTag(ctx context.Context, userId, tagId int) error {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	tag, ok := s.tags[tagId]
+	if !ok || tag.UserID != userId {
+		return ErrTagNotFound
+	}
+	delete(s.tags, tagId)
+
+	// Связи удаляются каскадно, как в Postgres
+	drop := func(links map[int][]int) {
+		for id, tagIds := range links {
+			links[id] = removeID(tagIds, tagId)
+		}
+	}
+	drop(s.worklogTags)
+	drop(s.taskTags)
+	return nil
+}
+
+func (r *memoryTagRepository) SetWorklogTags(ctx context.Context, userId, worklogId int, tagIds []int) ([]models.Tag, error) {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	w, ok := s.worklogs[worklogId]
+	if !ok || w.UserID != userId {
+		return nil, ErrWorklogNotFound
+	}
+	ids, err := s.checkTags(userId, tagIds)
+	if err != nil {
+		return nil, err
+	}
+	s.worklogTags[worklogId] = ids
+	return s.tagList(ids), nil
+}
+
+func (r *memoryTagRepository) SetTaskTags(ctx context.Context, userId, taskId int, tagIds []int) ([]models.Tag, error) {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	if _, ok := s.tasks[taskId]; !ok {
+		return nil, ErrTaskNotFound
+	}
+	ids, err := s.checkTags(userId, tagIds)
+	if err != nil {
+		return nil, err
+	}
+
+	// Теги других пользователей остаются на задаче
+	var kept []int
+	for _, id := range s.taskTags[taskId] {
+		if s.tags[id].UserID != userId {
+			kept = append(kept, id)
+		}
+	}
+	s.taskTags[taskId] = append(kept, ids...)
+	return s.tagList(ids), nil
+}
+
+// tagNameTaken reports whether the user has a tag called name other than the
+// one with exceptId. The caller must hold the lock.
+func (s *MemoryStore) tagNameTaken(userId int, name string, exceptId int) bool {
+	for id, tag := range s.tags {
+		if id != exceptId && tag.UserID == userId && tag.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTags returns the distinct tagIds, all of which must be tags of the
+// user. The caller must hold the lock.
+func (s *MemoryStore) checkTags(userId int, tagIds []int) ([]int, error) {
+	var ids []int
+	for _, id := range tagIds {
+		tag, ok := s.tags[id]
+		if !ok || tag.UserID != userId {
+			return nil, ErrTagNotFound
+		}
+		if !containsID(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// tagList returns copies of the tags, ordered by name and ID. The caller must
+// hold the lock.
+func (s *MemoryStore) tagList(ids []int) []models.Tag {
+	var tags []models.Tag
+	for _, id := range ids {
+		tags = append(tags, *s.tags[id])
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Name != tags[j].Name {
+			return tags[i].Name < tags[j].Name
+		}
+		return tags[i].ID < tags[j].ID
+	})
+	return tags
+}
+
+// logTags returns the tags of a worklog: its own and the ones its owner put
+// on its task. The caller must hold the lock.
+func (s *MemoryStore) logTags(w *models.Worklog) []int {
+	ids := append([]int(nil), s.worklogTags[w.ID]...)
+	for _, id := range s.taskTags[w.TaskID] {
+		if s.tags[id].UserID == w.UserID && !containsID(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func containsID(ids []int, id int) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func removeID(ids []int, id int) []int {
+	var kept []int
+	for _, v := range ids {
+		if v != id {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
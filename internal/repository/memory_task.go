@@ -0,0 +1,493 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+)
+
+type memoryTaskRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryTaskRepository(store *MemoryStore) TaskRepository {
+	return &memoryTaskRepository{store: store}
+}
+
+// GetWorklogs adds up the finished worklogs of a user that lie within
+// [startDate, endDate] by task. The dates are YYYY-MM-DD in local time, or
+// "-infinity" and "infinity" for an open end. With tags, only the worklogs
+// having one of them count.
+func (r *memoryTaskRepository) GetWorklogs(ctx context.Context, userId int, startDate, endDate string, tags []string) ([]models.Task, error) {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	worklogs, err := s.reportWorklogs(userId, startDate, endDate, tags)
+	if err != nil {
+		return nil, err
+	}
+	seconds := make(map[int]float64)
+	for _, w := range worklogs {
+		seconds[w.TaskID] += w.EndTime.Sub(w.StartTime).Seconds()
+	}
+
+	var tasks []models.Task
+	for _, id := range sortedIDs(seconds) {
+		hours, minutes := splitSeconds(seconds[id])
+		tasks = append(tasks, models.Task{
+			ID:           id,
+			Name:         s.tasks[id].name,
+			Description:  s.tasks[id].description,
+			TotalHours:   hours,
+			TotalMinutes: minutes,
+		})
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if tasks[i].TotalHours != tasks[j].TotalHours {
+			return tasks[i].TotalHours > tasks[j].TotalHours
+		}
+		return tasks[i].TotalMinutes > tasks[j].TotalMinutes
+	})
+	return tasks, nil
+}
+
+// GetTagTotals adds up the same worklogs as GetWorklogs by tag.
+func (r *memoryTaskRepository) GetTagTotals(ctx context.Context, userId int, startDate, endDate string, tags []string) ([]models.TagTotal, error) {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	worklogs, err := s.reportWorklogs(userId, startDate, endDate, tags)
+	if err != nil {
+		return nil, err
+	}
+	// Время без тегов собирается под ID 0
+	seconds := make(map[int]float64)
+	for _, w := range worklogs {
+		tagIds := s.logTags(w)
+		if len(tagIds) == 0 {
+			tagIds = []int{0}
+		}
+		for _, id := range tagIds {
+			seconds[id] += w.EndTime.Sub(w.StartTime).Seconds()
+		}
+	}
+
+	var totals []models.TagTotal
+	for _, id := range sortedIDs(seconds) {
+		var total models.TagTotal
+		if id != 0 {
+			tag := *s.tags[id]
+			total.Tag = &tag
+		}
+		total.TotalHours, total.TotalMinutes = splitSeconds(seconds[id])
+		totals = append(totals, total)
+	}
+	// ID 0 идёт первым, а должен быть последним среди равных
+	if len(totals) > 0 && totals[0].Tag == nil {
+		totals = append(totals[1:], totals[0])
+	}
+	sort.SliceStable(totals, func(i, j int) bool {
+		if totals[i].TotalHours != totals[j].TotalHours {
+			return totals[i].TotalHours > totals[j].TotalHours
+		}
+		return totals[i].TotalMinutes > totals[j].TotalMinutes
+	})
+	return totals, nil
+}
+
+// reportWorklogs returns the finished worklogs of a user within the period
+// that have one of the tags, or any worklogs if tags is empty. The caller
+// must hold the lock.
+func (s *MemoryStore) reportWorklogs(userId int, startDate, endDate string, tags []string) ([]*models.Worklog, error) {
+	from, err := parseMemoryDate(startDate)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseMemoryDate(endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	var worklogs []*models.Worklog
+	for _, id := range sortedIDs(s.worklogs) {
+		w := s.worklogs[id]
+		if w.UserID != userId || w.EndTime == nil || w.StartTime.Before(from) || w.EndTime.After(to) {
+			continue
+		}
+		if len(tags) > 0 && !s.hasTag(w, tags) {
+			continue
+		}
+		worklogs = append(worklogs, w)
+	}
+	return worklogs, nil
+}
+
+func (s *MemoryStore) hasTag(w *models.Worklog, names []string) bool {
+	for _, id := range s.logTags(w) {
+		for _, name := range names {
+			if s.tags[id].Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitSeconds turns a total into whole hours and minutes.
+func splitSeconds(total float64) (hours, minutes int) {
+	seconds := int(math.Floor(total))
+	return seconds / 3600, seconds / 60 % 60
+}
+
+func parseMemoryDate(date string) (time.Time, error) {
+	switch date {
+	case "-infinity":
+		return time.Time{}, nil
+	case "infinity":
+		return time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC), nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", date, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: %w", date, err)
+	}
+	return t, nil
+}
+
+func (r *memoryTaskRepository) StartTask(ctx context.Context, userId, taskId int, startTime time.Time, note string, tagIds []int) error {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userId]; !ok {
+		return ErrTaskNotFound
+	}
+	if _, ok := s.tasks[taskId]; !ok {
+		return ErrTaskNotFound
+	}
+	ids, err := s.checkTags(userId, tagIds)
+	if err != nil {
+		return err
+	}
+
+	startTime = dbTime(startTime)
+	id := s.nextID("worklogs")
+	s.worklogs[id] = &models.Worklog{
+		ID:             id,
+		UserID:         userId,
+		TaskID:         taskId,
+		StartTime:      startTime,
+		LastActivityAt: &startTime,
+		Note:           note,
+	}
+	s.worklogTags[id] = ids
+	return nil
+}
+
+func (r *memoryTaskRepository) StopTask(ctx context.Context, userId, taskId int, endTime time.Time, note string) error {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	endTime = dbTime(endTime)
+	stopped := 0
+	for _, w := range s.openWorklogs() {
+		if w.UserID == userId && w.TaskID == taskId {
+			end := endTime
+			w.EndTime = &end
+			w.Note = appendNote(w.Note, note)
+			stopped++
+		}
+	}
+	if stopped == 0 {
+		return ErrNoRunningTask
+	}
+	return nil
+}
+
+func (r *memoryTaskRepository) GetOpenWorklogs(ctx context.Context) ([]models.OpenWorklog, error) {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	var worklogs []models.OpenWorklog
+	for _, w := range s.openWorklogs() {
+		open := models.OpenWorklog{Worklog: models.Worklog{
+			ID:             w.ID,
+			UserID:         w.UserID,
+			TaskID:         w.TaskID,
+			StartTime:      w.StartTime,
+			LastActivityAt: copyTime(w.LastActivityAt),
+		}}
+		if policy := s.policyFor(w.UserID); policy != nil {
+			p := copyPolicy(policy)
+			open.Policy = &p
+		}
+		worklogs = append(worklogs, open)
+	}
+	sort.SliceStable(worklogs, func(i, j int) bool {
+		return worklogs[i].StartTime.Before(worklogs[j].StartTime)
+	})
+	return worklogs, nil
+}
+
+func (r *memoryTaskRepository) GetRunningWorklogs(ctx context.Context, userId int) ([]models.Worklog, error) {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	var worklogs []models.Worklog
+	for _, w := range s.openWorklogs() {
+		if w.UserID != userId {
+			continue
+		}
+		worklogs = append(worklogs, models.Worklog{
+			ID:             w.ID,
+			UserID:         w.UserID,
+			TaskID:         w.TaskID,
+			TaskName:       s.tasks[w.TaskID].name,
+			StartTime:      w.StartTime,
+			LastActivityAt: copyTime(w.LastActivityAt),
+			IdleStatus:     w.IdleStatus,
+			Note:           w.Note,
+			Tags:           s.tagList(s.worklogTags[w.ID]),
+		})
+	}
+	sort.SliceStable(worklogs, func(i, j int) bool {
+		return worklogs[i].StartTime.Before(worklogs[j].StartTime)
+	})
+	return worklogs, nil
+}
+
+func (r *memoryTaskRepository) AutoStopWorklog(ctx context.Context, worklogId int, endTime time.Time) error {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	w, ok := s.worklogs[worklogId]
+	if !ok || w.EndTime != nil {
+		return ErrNoRunningTask
+	}
+	endTime = dbTime(endTime)
+	w.EndTime = &endTime
+	w.AutoStopped = true
+	return nil
+}
+
+func (r *memoryTaskRepository) GetWorklog(ctx context.Context, userId, worklogId int) (*models.Worklog, error) {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	w, ok := s.worklogs[worklogId]
+	if !ok || w.UserID != userId {
+		return nil, ErrWorklogNotFound
+	}
+	c := copyWorklog(w)
+	c.Tags = s.tagList(s.worklogTags[w.ID])
+	return &c, nil
+}
+
+func (r *memoryTaskRepository) RecordActivity(ctx context.Context, userId, taskId int, at time.Time) (*models.Worklog, error) {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	at = dbTime(at)
+	var updated *models.Worklog
+	for _, w := range s.openWorklogs() {
+		if w.UserID != userId || w.TaskID != taskId {
+			continue
+		}
+		last := w.StartTime
+		if w.LastActivityAt != nil {
+			last = *w.LastActivityAt
+		}
+		if at.After(last) {
+			last = at
+		}
+		w.LastActivityAt = &last
+		// Пользователь вернулся — предложение обрезать простой снимается
+		if w.IdleStatus == models.IdleProposed && at.After(*w.IdleSince) {
+			w.IdleStatus = ""
+			w.IdleSince = nil
+		}
+		if updated == nil {
+			updated = w
+		}
+	}
+	if updated == nil {
+		return nil, ErrNoRunningTask
+	}
+	c := copyWorklog(updated)
+	return &c, nil
+}
+
+func (r *memoryTaskRepository) DetectIdle(ctx context.Context, inactiveSince time.Time, apply bool) ([]models.Worklog, error) {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	var worklogs []models.Worklog
+	for _, w := range s.openWorklogs() {
+		if w.LastActivityAt == nil || !w.LastActivityAt.Before(inactiveSince) {
+			continue
+		}
+		if w.IdleStatus != "" && !(w.IdleStatus == models.IdleDiscarded && w.LastActivityAt.After(*w.IdleSince)) {
+			continue
+		}
+
+		w.IdleSince = copyTime(w.LastActivityAt)
+		w.IdleStatus = models.IdleProposed
+		if apply {
+			w.IdleStatus = models.IdleApplied
+			w.EndTime = copyTime(w.LastActivityAt)
+		}
+		worklogs = append(worklogs, copyWorklog(w))
+	}
+	return worklogs, nil
+}
+
+func (r *memoryTaskRepository) ResolveIdle(ctx context.Context, userId, worklogId int, accept bool, resumeAt *time.Time) (*models.Worklog, error) {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	w, ok := s.worklogs[worklogId]
+	if !ok || w.UserID != userId {
+		return nil, ErrWorklogNotFound
+	}
+	if w.IdleStatus != models.IdleProposed {
+		return nil, ErrNoIdleProposal
+	}
+	// Обрезка не должна удлинять уже остановленный таймер
+	if accept && w.EndTime != nil && !w.EndTime.After(*w.IdleSince) {
+		return nil, ErrNoIdleProposal
+	}
+	running := w.EndTime == nil
+	w.IdleStatus = models.IdleDiscarded
+	if accept {
+		w.IdleStatus = models.IdleAccepted
+		w.EndTime = copyTime(w.IdleSince)
+	}
+
+	if accept && running && resumeAt != nil {
+		startTime := dbTime(*resumeAt)
+		id := s.nextID("worklogs")
+		s.worklogs[id] = &models.Worklog{
+			ID:             id,
+			UserID:         userId,
+			TaskID:         w.TaskID,
+			StartTime:      startTime,
+			LastActivityAt: &startTime,
+			Note:           w.Note,
+		}
+		s.worklogTags[id] = append([]int(nil), s.worklogTags[w.ID]...)
+	}
+	c := copyWorklog(w)
+	return &c, nil
+}
+
+func (r *memoryTaskRepository) CountOpenWorklogs(ctx context.Context) (int, error) {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer s.mu.Unlock()
+
+	return len(s.openWorklogs()), nil
+}
+
+func (r *memoryTaskRepository) CountPendingIdle(ctx context.Context) (int, error) {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, w := range s.worklogs {
+		if w.IdleStatus == models.IdleProposed {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// openWorklogs returns the running timers by ID. The caller must hold the
+// lock.
+func (s *MemoryStore) openWorklogs() []*models.Worklog {
+	var open []*models.Worklog
+	for _, id := range sortedIDs(s.worklogs) {
+		if w := s.worklogs[id]; w.EndTime == nil {
+			open = append(open, w)
+		}
+	}
+	return open
+}
+
+// policyFor returns the policy of a user, or else the one of their team. The
+// caller must hold the lock.
+func (s *MemoryStore) policyFor(userId int) *models.AutoStopPolicy {
+	var teamPolicy *models.AutoStopPolicy
+	team := ""
+	if u, ok := s.users[userId]; ok {
+		team = u.Team
+	}
+	for _, p := range s.policies {
+		if p.UserID != nil && *p.UserID == userId {
+			return p
+		}
+		if team != "" && p.Team == team {
+			teamPolicy = p
+		}
+	}
+	return teamPolicy
+}
+
+// appendNote adds the note given on stop to the one given on start.
+func appendNote(note, more string) string {
+	switch {
+	case more == "":
+		return note
+	case note == "":
+		return more
+	default:
+		return note + "\n" + more
+	}
+}
+
+func copyWorklog(w *models.Worklog) models.Worklog {
+	c := *w
+	c.EndTime = copyTime(w.EndTime)
+	c.LastActivityAt = copyTime(w.LastActivityAt)
+	c.IdleSince = copyTime(w.IdleSince)
+	return c
+}
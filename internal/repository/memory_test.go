@@ -0,0 +1,19 @@
+package repository
+
+import "testing"
+
+func TestMemoryRepositories(t *testing.T) {
+	testRepositories(t, func(t *testing.T) fixture {
+		store := NewMemoryStore()
+		return fixture{
+			users:    NewMemoryUserRepository(store),
+			tasks:    NewMemoryTaskRepository(store),
+			policies: NewMemoryAutoStopPolicyRepository(store),
+			tags:     NewMemoryTagRepository(store),
+			search:   NewMemorySearchRepository(store),
+			addTask: func(t *testing.T, name, description string) int {
+				return store.AddTask(name, description)
+			},
+		}
+	})
+}
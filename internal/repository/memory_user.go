@@ -0,0 +1,372 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+)
+
+// similarityThreshold is the default pg_trgm.similarity_threshold used by the
+// % operator.
+const similarityThreshold = 0.3
+
+type memoryUserRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryUserRepository(store *MemoryStore) UserRepository {
+	return &memoryUserRepository{store: store}
+}
+
+func (r *memoryUserRepository) CreateUser(ctx context.Context, user *models.User) error {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	if s.passportTaken(user.PassportNumber, 0) {
+		return ErrPassportExists
+	}
+
+	user.ID = s.nextID("users")
+	user.CreatedAt = dbTime(time.Now())
+	s.users[user.ID] = &memoryUser{User: models.User{
+		ID:             user.ID,
+		Name:           user.Name,
+		Surname:        user.Surname,
+		Patronymic:     user.Patronymic,
+		PassportNumber: user.PassportNumber,
+		Address:        user.Address,
+		CreatedAt:      user.CreatedAt,
+	}}
+	return nil
+}
+
+func (r *memoryUserRepository) GetUsers(ctx context.Context, params models.UserListParams) (*models.UserList, error) {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	order := strings.ToLower(params.Order)
+	if order == "" {
+		order = OrderAsc
+	}
+	if order != OrderAsc && order != OrderDesc {
+		return nil, ErrInvalidSort
+	}
+
+	sortBy := params.Sort
+	if sortBy == "" {
+		sortBy = "id"
+		if params.Filter.Search != "" {
+			sortBy, order = SortRelevance, OrderDesc
+		}
+	}
+
+	var users []*models.User
+	for _, id := range sortedIDs(s.users) {
+		if u := &s.users[id].User; matchesUserFilter(u, params.Filter) {
+			users = append(users, u)
+		}
+	}
+	list := &models.UserList{Total: len(users)}
+
+	if sortBy == SortRelevance {
+		if params.Filter.Search == "" {
+			return nil, ErrInvalidSort
+		}
+		if params.Cursor != "" {
+			return nil, ErrInvalidCursor
+		}
+		search := trigrams(params.Filter.Search)
+		score := make(map[int]float64, len(users))
+		for _, u := range users {
+			score[u.ID] = similarity(trigrams(fullName(u)), search)
+		}
+		sort.SliceStable(users, func(i, j int) bool {
+			return score[users[i].ID] > score[users[j].ID]
+		})
+	} else {
+		column, ok := userSortColumns[sortBy]
+		if !ok {
+			return nil, ErrInvalidSort
+		}
+		sign := 1
+		if order == OrderDesc {
+			sign = -1
+		}
+		sort.SliceStable(users, func(i, j int) bool {
+			return sign*compareUsers(column, users[i], column.value(users[j]), users[j].ID) < 0
+		})
+
+		if params.Cursor != "" {
+			cursor, err := decodeUserCursor(params.Cursor)
+			if err != nil {
+				return nil, err
+			}
+			if cursor.Sort != sortBy || cursor.Order != order {
+				return nil, ErrInvalidCursor
+			}
+			after := users[:0:0]
+			for _, u := range users {
+				if sign*compareUsers(column, u, cursor.Value, cursor.ID) > 0 {
+					after = append(after, u)
+				}
+			}
+			users = after
+		}
+	}
+
+	if params.Cursor == "" {
+		offset := (params.Page - 1) * params.Limit
+		if offset < 0 {
+			return nil, fmt.Errorf("OFFSET must not be negative")
+		}
+		if offset > len(users) {
+			offset = len(users)
+		}
+		users = users[offset:]
+	}
+
+	for i, u := range users {
+		if i == params.Limit {
+			last := &list.Users[len(list.Users)-1]
+			if sortBy != SortRelevance {
+				list.NextCursor = encodeUserCursor(userCursor{Sort: sortBy, Order: order, Value: userSortColumns[sortBy].value(last), ID: last.ID})
+			}
+			break
+		}
+		list.Users = append(list.Users, copyUser(u))
+	}
+
+	return list, nil
+}
+
+func (r *memoryUserRepository) DeleteUser(ctx context.Context, userId int) error {
+	return r.update(ctx, userId, func(u *memoryUser) bool {
+		if u.DeletedAt != nil {
+			return false
+		}
+		now := dbTime(time.Now())
+		u.DeletedAt = &now
+		return true
+	})
+}
+
+func (r *memoryUserRepository) RestoreUser(ctx context.Context, userId int) error {
+	return r.update(ctx, userId, func(u *memoryUser) bool {
+		if u.DeletedAt == nil || u.purged {
+			return false
+		}
+		u.DeletedAt = nil
+		return true
+	})
+}
+
+func (r *memoryUserRepository) PurgeUser(ctx context.Context, userId int) error {
+	return r.update(ctx, userId, func(u *memoryUser) bool {
+		if u.purged {
+			return false
+		}
+		u.Name, u.Surname, u.Patronymic, u.Address = "", "", "", ""
+		u.PassportNumber = "purged-" + strconv.Itoa(u.ID)
+		if u.DeletedAt == nil {
+			now := dbTime(time.Now())
+			u.DeletedAt = &now
+		}
+		u.purged = true
+		return true
+	})
+}
+
+// update applies change to a user and reports ErrUserNotFound when the user
+// doesn't exist or change didn't apply to them.
+func (r *memoryUserRepository) update(ctx context.Context, userId int, change func(u *memoryUser) bool) error {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	u, ok := s.users[userId]
+	if !ok || !change(u) {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *memoryUserRepository) UpdateUser(ctx context.Context, user *models.User) error {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	u, ok := s.users[user.ID]
+	if !ok || u.DeletedAt != nil {
+		return ErrUserNotFound
+	}
+	if s.passportTaken(user.PassportNumber, user.ID) {
+		return ErrPassportExists
+	}
+
+	u.Name = user.Name
+	u.Surname = user.Surname
+	u.Patronymic = user.Patronymic
+	u.PassportNumber = user.PassportNumber
+	u.Address = user.Address
+	u.Team = user.Team
+	return nil
+}
+
+func (r *memoryUserRepository) GetUserByID(ctx context.Context, userId int) (*models.User, error) {
+	return r.getUser(ctx, func(u *memoryUser) bool {
+		return u.ID == userId && u.DeletedAt == nil
+	})
+}
+
+func (r *memoryUserRepository) GetUserByPassport(ctx context.Context, passportNumber string) (*models.User, error) {
+	return r.getUser(ctx, func(u *memoryUser) bool {
+		return u.PassportNumber == passportNumber
+	})
+}
+
+func (r *memoryUserRepository) getUser(ctx context.Context, match func(u *memoryUser) bool) (*models.User, error) {
+	s := r.store
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	for _, id := range sortedIDs(s.users) {
+		if u := s.users[id]; match(u) {
+			user := copyUser(&u.User)
+			return &user, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+// passportTaken reports whether a user other than exceptId, deleted or not,
+// has the passport number. The caller must hold the lock.
+func (s *MemoryStore) passportTaken(passportNumber string, exceptId int) bool {
+	for id, u := range s.users {
+		if id != exceptId && u.PassportNumber == passportNumber {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesUserFilter(u *models.User, filter models.UserFilter) bool {
+	contains := [][2]string{
+		{u.Name, filter.Name},
+		{u.Surname, filter.Surname},
+		{u.Patronymic, filter.Patronymic},
+		{u.Address, filter.Address},
+	}
+	for _, c := range contains {
+		if c[1] != "" && !strings.Contains(strings.ToLower(c[0]), strings.ToLower(c[1])) {
+			return false
+		}
+	}
+
+	if filter.PassportNumber != "" && u.PassportNumber != filter.PassportNumber {
+		return false
+	}
+	if filter.Search != "" && similarity(trigrams(fullName(u)), trigrams(filter.Search)) < similarityThreshold {
+		return false
+	}
+	if filter.CreatedFrom != nil && u.CreatedAt.Before(*filter.CreatedFrom) {
+		return false
+	}
+	if filter.CreatedTo != nil && u.CreatedAt.After(*filter.CreatedTo) {
+		return false
+	}
+
+	switch {
+	case filter.Active != nil:
+		return *filter.Active == (u.DeletedAt == nil)
+	case !filter.IncludeDeleted:
+		return u.DeletedAt == nil
+	}
+	return true
+}
+
+// compareUsers compares the sort key and ID of u with a sort key in cursor
+// form and an ID.
+func compareUsers(column userSortColumn, u *models.User, value string, id int) int {
+	var c int
+	switch column.cast {
+	case "int":
+		a, _ := strconv.Atoi(column.value(u))
+		b, _ := strconv.Atoi(value)
+		c = a - b
+	case "timestamptz":
+		a, _ := time.Parse(time.RFC3339Nano, column.value(u))
+		b, _ := time.Parse(time.RFC3339Nano, value)
+		c = a.Compare(b)
+	default:
+		c = strings.Compare(column.value(u), value)
+	}
+	if c == 0 {
+		c = u.ID - id
+	}
+	return c
+}
+
+func copyUser(u *models.User) models.User {
+	c := *u
+	c.DeletedAt = copyTime(u.DeletedAt)
+	if u.TaskIDs != nil {
+		c.TaskIDs = append([]int(nil), u.TaskIDs...)
+	}
+	return c
+}
+
+// fullName matches the userFullName expression.
+func fullName(u *models.User) string {
+	return u.Surname + " " + u.Name + " " + u.Patronymic
+}
+
+// trigrams splits s into the trigrams pg_trgm would: every word of letters
+// and digits is lowercased and padded with two spaces in front and one
+// behind.
+func trigrams(s string) map[string]bool {
+	set := make(map[string]bool)
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
+
+// similarity is the share of trigrams the two sets have in common, like
+// pg_trgm's similarity().
+func similarity(a, b map[string]bool) float64 {
+	common := 0
+	for t := range a {
+		if b[t] {
+			common++
+		}
+	}
+	all := len(a) + len(b) - common
+	if all == 0 {
+		return 0
+	}
+	return float64(common) / float64(all)
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"os"
+	"testing"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/testdb"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testdb.Main(m))
+}
+
+func TestPostgresRepositories(t *testing.T) {
+	db := testdb.Open(t)
+
+	testRepositories(t, func(t *testing.T) fixture {
+		testdb.Reset(t, db)
+		return fixture{
+			users:    NewUserRepository(db, Timeouts{}),
+			tasks:    NewTaskRepository(db, Timeouts{}),
+			policies: NewAutoStopPolicyRepository(db, Timeouts{}),
+			tags:     NewTagRepository(db, Timeouts{}),
+			search:   NewSearchRepository(db, Timeouts{}),
+			addTask: func(t *testing.T, name, description string) int {
+				return testdb.AddTask(t, db, name, description)
+			},
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+)
+
+// searchConfig is the text search configuration of the search_vector
+// columns, set in migration 000008. Queries must be parsed with the same one
+// to match their lexemes. simple only lower-cases words: texts aren't all
+// English, and the in-memory search matches the same way.
+const searchConfig = "simple"
+
+// searchHeadline holds the ts_headline options of the snippets.
+const searchHeadline = `StartSel=<b>, StopSel=</b>, MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=" … "`
+
+type SearchRepository interface {
+	Search(ctx context.Context, params models.SearchParams) ([]models.SearchHit, error)
+}
+
+type searchRepository struct {
+	db       *sql.DB
+	timeouts Timeouts
+}
+
+func NewSearchRepository(db *sql.DB, timeouts Timeouts) SearchRepository {
+	return &searchRepository{db: db, timeouts: timeouts}
+}
+
+// Search ranks matching tasks and worklogs together and returns the best
+// params.Limit of them. Snippets are only built for those.
+func (r *searchRepository) Search(ctx context.Context, params models.SearchParams) (_ []models.SearchHit, err error) {
+	ctx, end := begin(ctx, "searchRepository.Search", r.timeouts.Report)
+	defer end(&err)
+
+	// Задача подходит под фильтры, если по ней есть подходящие записи
+	query := fmt.Sprintf(`
+		WITH q AS (
+			SELECT websearch_to_tsquery('%[1]s', $1) AS query
+		), hits AS (
+			SELECT 'task' AS type, t.id, t.id AS task_id, ts_rank(t.search_vector, q.query) AS rank
+			FROM tasks t, q
+			WHERE t.search_vector @@ q.query AND (
+				($2::int IS NULL AND $3::timestamptz IS NULL AND $4::timestamptz IS NULL) OR EXISTS (
+					SELECT 1
+					FROM worklogs w
+					WHERE w.task_id = t.id
+						AND ($2::int IS NULL OR w.user_id = $2)
+						AND ($3::timestamptz IS NULL OR w.start_time >= $3)
+						AND ($4::timestamptz IS NULL OR w.start_time < $4)
+				)
+			)
+			UNION ALL
+			SELECT 'worklog', w.id, w.task_id, ts_rank(w.search_vector, q.query)
+			FROM worklogs w, q
+			WHERE w.search_vector @@ q.query
+				AND ($2::int IS NULL OR w.user_id = $2)
+				AND ($3::timestamptz IS NULL OR w.start_time >= $3)
+				AND ($4::timestamptz IS NULL OR w.start_time < $4)
+			ORDER BY rank DESC, type, id DESC
+			LIMIT $5
+		)
+		SELECT h.type, h.task_id, t.name, w.id, w.user_id, w.start_time, w.end_time,
+			round(h.rank::numeric, 4)::float8,
+			ts_headline('%[1]s', %[2]s, q.query, $6)
+		FROM hits h
+		JOIN tasks t ON t.id = h.task_id
+		LEFT JOIN worklogs w ON h.type = 'worklog' AND w.id = h.id
+		CROSS JOIN q
+		ORDER BY h.rank DESC, h.type, h.id DESC
+	`, searchConfig, escapeHTML(`CASE WHEN h.type = 'task' THEN concat_ws(': ', t.name, NULLIF(t.description, '')) ELSE w.note END`))
+
+	var userId *int
+	if params.UserID != 0 {
+		userId = &params.UserID
+	}
+	rows, err := r.db.QueryContext(ctx, query, params.Query, userId, params.From, params.To, params.Limit, searchHeadline)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []models.SearchHit
+
+	for rows.Next() {
+		var hit models.SearchHit
+		var worklogId, worklogUserId sql.NullInt64
+		err := rows.Scan(&hit.Type, &hit.TaskID, &hit.TaskName, &worklogId, &worklogUserId,
+			&hit.StartTime, &hit.EndTime, &hit.Rank, &hit.Snippet)
+		if err != nil {
+			return nil, err
+		}
+		hit.WorklogID = int(worklogId.Int64)
+		hit.UserID = int(worklogUserId.Int64)
+		hits = append(hits, hit)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return hits, nil
+}
+
+// escapeHTML wraps a text expression so that its value can be put into HTML.
+// ts_headline leaves the entities alone.
+func escapeHTML(expr string) string {
+	return fmt.Sprintf(`replace(replace(replace(%s, '&', '&amp;'), '<', '&lt;'), '>', '&gt;')`, expr)
+}
@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrTagNotFound = errors.New("tag not found")
+	ErrTagExists   = errors.New("tag name already exists")
+)
+
+const tagNameUniqueConstraint = "tags_user_id_name_key"
+
+type TagRepository interface {
+	GetTags(ctx context.Context, userId int) ([]models.Tag, error)
+	CreateTag(ctx context.Context, tag *models.Tag) error
+	UpdateTag(ctx context.Context, tag *models.Tag) error
+	DeleteTag(ctx context.Context, userId, tagId int) error
+	SetWorklogTags(ctx context.Context, userId, worklogId int, tagIds []int) ([]models.Tag, error)
+	SetTaskTags(ctx context.Context, userId, taskId int, tagIds []int) ([]models.Tag, error)
+}
+
+type tagRepository struct {
+	db       *sql.DB
+	timeouts Timeouts
+}
+
+func NewTagRepository(db *sql.DB, timeouts Timeouts) TagRepository {
+	return &tagRepository{db: db, timeouts: timeouts}
+}
+
+func (r *tagRepository) GetTags(ctx context.Context, userId int) (_ []models.Tag, err error) {
+	ctx, end := begin(ctx, "tagRepository.GetTags", r.timeouts.Query)
+	defer end(&err)
+
+	query := `
+		SELECT id, user_id, name, color
+		FROM tags
+		WHERE user_id = $1
+		ORDER BY name COLLATE "C", id
+	`
+	return queryTags(ctx, r.db, query, userId)
+}
+
+func (r *tagRepository) CreateTag(ctx context.Context, tag *models.Tag) (err error) {
+	ctx, end := begin(ctx, "tagRepository.CreateTag", r.timeouts.Query)
+	defer end(&err)
+
+	query := `
+		INSERT INTO tags (user_id, name, color)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+	err = r.db.QueryRowContext(ctx, query, tag.UserID, tag.Name, tag.Color).Scan(&tag.ID)
+	if _, ok := isPQError(err, pqForeignKeyViolation); ok {
+		return ErrUserNotFound
+	}
+	return mapTagWriteError(err)
+}
+
+// UpdateTag renames and recolors a tag of tag.UserID.
+func (r *tagRepository) UpdateTag(ctx context.Context, tag *models.Tag) (err error) {
+	ctx, end := begin(ctx, "tagRepository.UpdateTag", r.timeouts.Query)
+	defer end(&err)
+
+	query := `
+		UPDATE tags
+		SET name = $1, color = $2
+		WHERE id = $3 AND user_id = $4
+	`
+	result, err := r.db.ExecContext(ctx, query, tag.Name, tag.Color, tag.ID, tag.UserID)
+	if err != nil {
+		return mapTagWriteError(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrTagNotFound
+	}
+	return nil
+}
+
+// DeleteTag deletes a tag and removes it from worklogs and tasks.
+func (r *tagRepository) DeleteTag(ctx context.Context, userId, tagId int) (err error) {
+	ctx, end := begin(ctx, "tagRepository.DeleteTag", r.timeouts.Query)
+	defer end(&err)
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM tags WHERE id = $1 AND user_id = $2", tagId, userId)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrTagNotFound
+	}
+	return nil
+}
+
+// SetWorklogTags replaces the tags of a worklog of the user and returns them.
+func (r *tagRepository) SetWorklogTags(ctx context.Context, userId, worklogId int, tagIds []int) (_ []models.Tag, err error) {
+	ctx, end := begin(ctx, "tagRepository.SetWorklogTags", r.timeouts.Query)
+	defer end(&err)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Блокировка записи не даёт удалить её до конца транзакции
+	var found int
+	err = tx.QueryRowContext(ctx, "SELECT 1 FROM worklogs WHERE id = $1 AND user_id = $2 FOR UPDATE", worklogId, userId).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWorklogNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setWorklogTags(ctx, tx, userId, worklogId, tagIds); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT g.id, g.user_id, g.name, g.color
+		FROM worklog_tags wt
+		JOIN tags g ON g.id = wt.tag_id
+		WHERE wt.worklog_id = $1
+		ORDER BY g.name COLLATE "C", g.id
+	`
+	tags, err := queryTags(ctx, tx, query, worklogId)
+	if err != nil {
+		return nil, err
+	}
+	return tags, tx.Commit()
+}
+
+// SetTaskTags replaces the tags the user has put on a task and returns them.
+// Tags of other users on the same task stay as they are.
+func (r *tagRepository) SetTaskTags(ctx context.Context, userId, taskId int, tagIds []int) (_ []models.Tag, err error) {
+	ctx, end := begin(ctx, "tagRepository.SetTaskTags", r.timeouts.Query)
+	defer end(&err)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var found int
+	err = tx.QueryRowContext(ctx, "SELECT 1 FROM tasks WHERE id = $1 FOR SHARE", taskId).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		DELETE FROM task_tags
+		WHERE task_id = $1 AND tag_id IN (SELECT id FROM tags WHERE user_id = $2)
+	`
+	if _, err := tx.ExecContext(ctx, query, taskId, userId); err != nil {
+		return nil, err
+	}
+	query = `
+		INSERT INTO task_tags (task_id, tag_id)
+		SELECT $1::int, id FROM tags WHERE user_id = $2 AND id = ANY($3)
+	`
+	if err := insertTags(ctx, tx, query, taskId, userId, tagIds); err != nil {
+		return nil, err
+	}
+
+	query = `
+		SELECT g.id, g.user_id, g.name, g.color
+		FROM task_tags tt
+		JOIN tags g ON g.id = tt.tag_id
+		WHERE tt.task_id = $1 AND g.user_id = $2
+		ORDER BY g.name COLLATE "C", g.id
+	`
+	tags, err := queryTags(ctx, tx, query, taskId, userId)
+	if err != nil {
+		return nil, err
+	}
+	return tags, tx.Commit()
+}
+
+// setWorklogTags replaces the tags of a worklog within tx.
+func setWorklogTags(ctx context.Context, tx *sql.Tx, userId, worklogId int, tagIds []int) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM worklog_tags WHERE worklog_id = $1", worklogId); err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO worklog_tags (worklog_id, tag_id)
+		SELECT $1::int, id FROM tags WHERE user_id = $2 AND id = ANY($3)
+	`
+	return insertTags(ctx, tx, query, worklogId, userId, tagIds)
+}
+
+// insertTags runs an INSERT ... SELECT of the tags of userId among tagIds and
+// fails with ErrTagNotFound unless every one of them was found.
+func insertTags(ctx context.Context, tx *sql.Tx, query string, id, userId int, tagIds []int) error {
+	ids := distinctIDs(tagIds)
+	if len(ids) == 0 {
+		return nil
+	}
+	result, err := tx.ExecContext(ctx, query, id, userId, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != int64(len(ids)) {
+		return ErrTagNotFound
+	}
+	return nil
+}
+
+func distinctIDs(ids []int) []int64 {
+	seen := make(map[int]bool, len(ids))
+	var distinct []int64
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			distinct = append(distinct, int64(id))
+		}
+	}
+	return distinct
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// queryTags runs a query selecting id, user_id, name and color of tags.
+func queryTags(ctx context.Context, db queryer, query string, args ...any) ([]models.Tag, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []models.Tag
+
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.Color); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// mapTagWriteError translates constraint violations on the tags table into
+// repository errors.
+func mapTagWriteError(err error) error {
+	if pqErr, ok := isPQError(err, pqUniqueViolation); ok && pqErr.Constraint == tagNameUniqueConstraint {
+		return ErrTagExists
+	}
+	return err
+}
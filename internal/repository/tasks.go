@@ -1,44 +1,103 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+	"github.com/lib/pq"
 )
 
 type TaskRepository interface {
-	GetWorklogs(userId int, startDate, endDate string) ([]models.Task, error)
-	StartTask(userId, taskId int, startTime time.Time) error
-	StopTask(userId, taskId int, endTime time.Time) error
+	GetWorklogs(ctx context.Context, userId int, startDate, endDate string, tags []string) ([]models.Task, error)
+	GetTagTotals(ctx context.Context, userId int, startDate, endDate string, tags []string) ([]models.TagTotal, error)
+	StartTask(ctx context.Context, userId, taskId int, startTime time.Time, note string, tagIds []int) error
+	StopTask(ctx context.Context, userId, taskId int, endTime time.Time, note string) error
+	GetOpenWorklogs(ctx context.Context) ([]models.OpenWorklog, error)
+	GetRunningWorklogs(ctx context.Context, userId int) ([]models.Worklog, error)
+	AutoStopWorklog(ctx context.Context, worklogId int, endTime time.Time) error
+	GetWorklog(ctx context.Context, userId, worklogId int) (*models.Worklog, error)
+	RecordActivity(ctx context.Context, userId, taskId int, at time.Time) (*models.Worklog, error)
+	DetectIdle(ctx context.Context, inactiveSince time.Time, apply bool) ([]models.Worklog, error)
+	ResolveIdle(ctx context.Context, userId, worklogId int, accept bool, resumeAt *time.Time) (*models.Worklog, error)
+	CountOpenWorklogs(ctx context.Context) (int, error)
+	CountPendingIdle(ctx context.Context) (int, error)
 }
 
 type taskRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	timeouts Timeouts
 }
 
-func NewTaskRepository(db *sql.DB) TaskRepository {
-	return &taskRepository{db: db}
+func NewTaskRepository(db *sql.DB, timeouts Timeouts) TaskRepository {
+	return &taskRepository{db: db, timeouts: timeouts}
 }
 
-func (r *taskRepository) GetWorklogs(userId int, startDate, endDate string) ([]models.Task, error) {
-	query := `
-		SELECT 
-			t.id, t.name, t.description, SUM(EXTRACT(EPOCH FROM (w.end_time - w.start_time))/3600) as total_hours, 
-			SUM(EXTRACT(EPOCH FROM (w.end_time - w.start_time))/60) % 60 as total_minutes
-		FROM 
-			tasks t
-		JOIN 
-			worklogs w ON t.id = w.task_id
-		WHERE 
-			w.user_id = $1 AND w.start_time >= $2 AND w.end_time <= $3
-		GROUP BY 
-			t.id
-		ORDER BY 
-			total_hours DESC, total_minutes DESC;
+// reportWorklogs selects, as filtered, the finished worklogs of user $1 that
+// lie within [$2, $3] and have one of the tags named in $4, or all of them if
+// $4 is empty. log_tags holds the tags of each worklog: its own and the ones
+// the user put on its task.
+const reportWorklogs = `
+	WITH logs AS (
+		SELECT id, task_id, EXTRACT(EPOCH FROM (end_time - start_time)) AS seconds
+		FROM worklogs
+		WHERE user_id = $1 AND start_time >= $2 AND end_time <= $3
+	), log_tags AS (
+		SELECT wt.worklog_id, wt.tag_id
+		FROM logs l
+		JOIN worklog_tags wt ON wt.worklog_id = l.id
+		UNION
+		SELECT l.id, tt.tag_id
+		FROM logs l
+		JOIN task_tags tt ON tt.task_id = l.task_id
+		JOIN tags g ON g.id = tt.tag_id
+		WHERE g.user_id = $1
+	), filtered AS (
+		SELECT l.*
+		FROM logs l
+		WHERE cardinality($4::text[]) = 0 OR EXISTS (
+			SELECT 1
+			FROM log_tags lt
+			JOIN tags g ON g.id = lt.tag_id
+			WHERE lt.worklog_id = l.id AND g.name = ANY($4)
+		)
+	)
+`
+
+// tagNames passes tag names as a text[] parameter.
+func tagNames(tags []string) pq.StringArray {
+	// nil передаётся как NULL, а не пустой массив
+	if tags == nil {
+		return pq.StringArray{}
+	}
+	return pq.StringArray(tags)
+}
+
+func (r *taskRepository) GetWorklogs(ctx context.Context, userId int, startDate, endDate string, tags []string) (_ []models.Task, err error) {
+	ctx, end := begin(ctx, "taskRepository.GetWorklogs", r.timeouts.Report)
+	defer end(&err)
+
+	// Часы и минуты считаются из общего числа секунд, чтобы получились целые
+	query := reportWorklogs + `
+		SELECT
+			id, name, description, seconds / 3600 AS total_hours, seconds / 60 % 60 AS total_minutes
+		FROM (
+			SELECT
+				t.id, t.name, t.description, FLOOR(SUM(f.seconds))::bigint AS seconds
+			FROM
+				tasks t
+			JOIN
+				filtered f ON t.id = f.task_id
+			GROUP BY
+				t.id
+		) totals
+		ORDER BY
+			total_hours DESC, total_minutes DESC, id
 	`
 
-	rows, err := r.db.Query(query, userId, startDate, endDate)
+	rows, err := r.db.QueryContext(ctx, query, userId, startDate, endDate, tagNames(tags))
 	if err != nil {
 		return nil, err
 	}
@@ -62,21 +121,451 @@ func (r *taskRepository) GetWorklogs(userId int, startDate, endDate string) ([]m
 	return tasks, nil
 }
 
-func (r *taskRepository) StartTask(userId, taskId int, startTime time.Time) error {
+// GetTagTotals adds up the same worklogs as GetWorklogs by tag. A worklog
+// with several tags counts towards each of them; the time without tags comes
+// last among equal totals.
+func (r *taskRepository) GetTagTotals(ctx context.Context, userId int, startDate, endDate string, tags []string) (_ []models.TagTotal, err error) {
+	ctx, end := begin(ctx, "taskRepository.GetTagTotals", r.timeouts.Report)
+	defer end(&err)
+
+	query := reportWorklogs + `
+		SELECT
+			id, user_id, name, color, seconds / 3600 AS total_hours, seconds / 60 % 60 AS total_minutes
+		FROM (
+			SELECT
+				g.id, g.user_id, g.name, g.color, FLOOR(SUM(f.seconds))::bigint AS seconds
+			FROM
+				filtered f
+			LEFT JOIN
+				log_tags lt ON lt.worklog_id = f.id
+			LEFT JOIN
+				tags g ON g.id = lt.tag_id
+			GROUP BY
+				g.id
+		) totals
+		ORDER BY
+			total_hours DESC, total_minutes DESC, id NULLS LAST
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userId, startDate, endDate, tagNames(tags))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []models.TagTotal
+
+	for rows.Next() {
+		var total models.TagTotal
+		var id, tagUserId sql.NullInt64
+		var name, color sql.NullString
+		err := rows.Scan(&id, &tagUserId, &name, &color, &total.TotalHours, &total.TotalMinutes)
+		if err != nil {
+			return nil, err
+		}
+		if id.Valid {
+			total.Tag = &models.Tag{ID: int(id.Int64), UserID: int(tagUserId.Int64), Name: name.String, Color: color.String}
+		}
+		totals = append(totals, total)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return totals, nil
+}
+
+// StartTask starts a timer with a note and tags of the user.
+func (r *taskRepository) StartTask(ctx context.Context, userId, taskId int, startTime time.Time, note string, tagIds []int) (err error) {
+	ctx, end := begin(ctx, "taskRepository.StartTask", r.timeouts.Query)
+	defer end(&err)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO worklogs (user_id, task_id, start_time)
-		VALUES ($1, $2, $3)
+		INSERT INTO worklogs (user_id, task_id, start_time, last_activity_at, note)
+		VALUES ($1, $2, $3, $3, $4)
+		RETURNING id
 	`
-	_, err := r.db.Exec(query, userId, taskId, startTime)
-	return err
+	var worklogId int
+	err = tx.QueryRowContext(ctx, query, userId, taskId, startTime, note).Scan(&worklogId)
+	if _, ok := isPQError(err, pqForeignKeyViolation); ok {
+		return ErrTaskNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := setWorklogTags(ctx, tx, userId, worklogId, tagIds); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (r *taskRepository) StopTask(userId, taskId int, endTime time.Time) error {
+// StopTask stops the running timers of a task. A non-empty note is added to
+// the one given at the start on a new line.
+func (r *taskRepository) StopTask(ctx context.Context, userId, taskId int, endTime time.Time, note string) (err error) {
+	ctx, end := begin(ctx, "taskRepository.StopTask", r.timeouts.Query)
+	defer end(&err)
+
 	query := `
 		UPDATE worklogs
-		SET end_time = $1
+		SET end_time = $1, note = CASE WHEN $4::text = '' THEN note WHEN note = '' THEN $4 ELSE note || E'\n' || $4 END
 		WHERE user_id = $2 AND task_id = $3 AND end_time IS NULL
 	`
-	_, err := r.db.Exec(query, endTime, userId, taskId)
-	return err
+	result, err := r.db.ExecContext(ctx, query, endTime, userId, taskId, note)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNoRunningTask
+	}
+	return nil
+}
+
+// GetOpenWorklogs returns all running timers with the auto-stop policy of
+// their owner. A user policy takes precedence over the policy of their team.
+func (r *taskRepository) GetOpenWorklogs(ctx context.Context) (_ []models.OpenWorklog, err error) {
+	ctx, end := begin(ctx, "taskRepository.GetOpenWorklogs", r.timeouts.Query)
+	defer end(&err)
+
+	query := `
+		SELECT
+			w.id, w.user_id, w.task_id, w.start_time, w.last_activity_at,
+			p.id, p.user_id, COALESCE(p.team, ''), p.max_duration_seconds, p.stop_at, p.cap_seconds
+		FROM
+			worklogs w
+		JOIN
+			users u ON u.id = w.user_id
+		LEFT JOIN LATERAL (
+			SELECT * FROM auto_stop_policies ap
+			WHERE ap.user_id = w.user_id OR (ap.team IS NOT NULL AND ap.team = u.team)
+			ORDER BY ap.user_id IS NULL
+			LIMIT 1
+		) p ON TRUE
+		WHERE
+			w.end_time IS NULL
+		ORDER BY
+			w.start_time
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var worklogs []models.OpenWorklog
+
+	for rows.Next() {
+		var w models.OpenWorklog
+		var policy nullablePolicy
+		err := rows.Scan(&w.ID, &w.UserID, &w.TaskID, &w.StartTime, &w.LastActivityAt,
+			&policy.ID, &policy.UserID, &policy.Team, &policy.MaxDurationSeconds, &policy.StopAt, &policy.CapSeconds)
+		if err != nil {
+			return nil, err
+		}
+		w.Policy = policy.toModel()
+		worklogs = append(worklogs, w)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return worklogs, nil
+}
+
+// GetRunningWorklogs returns the running timers of a user with the names of
+// their tasks, oldest first.
+func (r *taskRepository) GetRunningWorklogs(ctx context.Context, userId int) (_ []models.Worklog, err error) {
+	ctx, end := begin(ctx, "taskRepository.GetRunningWorklogs", r.timeouts.Query)
+	defer end(&err)
+
+	query := `
+		SELECT
+			w.id, w.user_id, w.task_id, t.name, w.start_time, w.last_activity_at, COALESCE(w.idle_status, ''), w.note
+		FROM
+			worklogs w
+		JOIN
+			tasks t ON t.id = w.task_id
+		WHERE
+			w.user_id = $1 AND w.end_time IS NULL
+		ORDER BY
+			w.start_time
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var worklogs []models.Worklog
+
+	for rows.Next() {
+		var w models.Worklog
+		err := rows.Scan(&w.ID, &w.UserID, &w.TaskID, &w.TaskName, &w.StartTime, &w.LastActivityAt, &w.IdleStatus, &w.Note)
+		if err != nil {
+			return nil, err
+		}
+		worklogs = append(worklogs, w)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadTags(ctx, worklogs); err != nil {
+		return nil, err
+	}
+	return worklogs, nil
+}
+
+// loadTags fills in the own tags of the worklogs.
+func (r *taskRepository) loadTags(ctx context.Context, worklogs []models.Worklog) error {
+	if len(worklogs) == 0 {
+		return nil
+	}
+	index := make(map[int]int, len(worklogs))
+	ids := make(pq.Int64Array, len(worklogs))
+	for i, w := range worklogs {
+		index[w.ID] = i
+		ids[i] = int64(w.ID)
+	}
+
+	query := `
+		SELECT wt.worklog_id, g.id, g.user_id, g.name, g.color
+		FROM worklog_tags wt
+		JOIN tags g ON g.id = wt.tag_id
+		WHERE wt.worklog_id = ANY($1)
+		ORDER BY g.name COLLATE "C", g.id
+	`
+	rows, err := r.db.QueryContext(ctx, query, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var worklogId int
+		var tag models.Tag
+		if err := rows.Scan(&worklogId, &tag.ID, &tag.UserID, &tag.Name, &tag.Color); err != nil {
+			return err
+		}
+		w := &worklogs[index[worklogId]]
+		w.Tags = append(w.Tags, tag)
+	}
+	return rows.Err()
+}
+
+// AutoStopWorklog closes a running timer on behalf of the system and marks it
+// as auto-stopped.
+func (r *taskRepository) AutoStopWorklog(ctx context.Context, worklogId int, endTime time.Time) (err error) {
+	ctx, end := begin(ctx, "taskRepository.AutoStopWorklog", r.timeouts.Query)
+	defer end(&err)
+
+	query := `
+		UPDATE worklogs
+		SET end_time = $1, auto_stopped = TRUE
+		WHERE id = $2 AND end_time IS NULL
+	`
+	result, err := r.db.ExecContext(ctx, query, endTime, worklogId)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNoRunningTask
+	}
+	return nil
+}
+
+const worklogColumns = `id, user_id, task_id, start_time, end_time, last_activity_at, auto_stopped, idle_since, COALESCE(idle_status, ''), note`
+
+func scanWorklog(row interface{ Scan(...any) error }) (*models.Worklog, error) {
+	var w models.Worklog
+	err := row.Scan(&w.ID, &w.UserID, &w.TaskID, &w.StartTime, &w.EndTime, &w.LastActivityAt, &w.AutoStopped, &w.IdleSince, &w.IdleStatus, &w.Note)
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (r *taskRepository) GetWorklog(ctx context.Context, userId, worklogId int) (_ *models.Worklog, err error) {
+	ctx, end := begin(ctx, "taskRepository.GetWorklog", r.timeouts.Query)
+	defer end(&err)
+
+	query := `SELECT ` + worklogColumns + ` FROM worklogs WHERE id = $1 AND user_id = $2`
+	w, err := scanWorklog(r.db.QueryRowContext(ctx, query, worklogId, userId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWorklogNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	worklogs := []models.Worklog{*w}
+	if err := r.loadTags(ctx, worklogs); err != nil {
+		return nil, err
+	}
+	return &worklogs[0], nil
+}
+
+// RecordActivity moves the last activity of the running timer forward to at.
+// It never moves it backwards, so late heartbeats are harmless. Activity after
+// the start of a proposed idle tail withdraws the proposal.
+func (r *taskRepository) RecordActivity(ctx context.Context, userId, taskId int, at time.Time) (_ *models.Worklog, err error) {
+	ctx, end := begin(ctx, "taskRepository.RecordActivity", r.timeouts.Query)
+	defer end(&err)
+
+	query := `
+		UPDATE worklogs
+		SET last_activity_at = GREATEST(COALESCE(last_activity_at, start_time), $3),
+			idle_since = CASE WHEN idle_status = 'proposed' AND $3 > idle_since THEN NULL ELSE idle_since END,
+			idle_status = CASE WHEN idle_status = 'proposed' AND $3 > idle_since THEN NULL ELSE idle_status END
+		WHERE user_id = $1 AND task_id = $2 AND end_time IS NULL
+		RETURNING ` + worklogColumns
+	w, err := scanWorklog(r.db.QueryRowContext(ctx, query, userId, taskId, at))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoRunningTask
+	}
+	return w, err
+}
+
+// DetectIdle flags running timers without activity since inactiveSince. The
+// idle tail starts at the last activity. With apply the timers are stopped
+// there right away, otherwise the trim is only proposed to the user.
+// A timer whose earlier proposal was discarded is flagged again only after
+// new activity.
+func (r *taskRepository) DetectIdle(ctx context.Context, inactiveSince time.Time, apply bool) (_ []models.Worklog, err error) {
+	ctx, end := begin(ctx, "taskRepository.DetectIdle", r.timeouts.Query)
+	defer end(&err)
+
+	set := `idle_since = last_activity_at, idle_status = 'proposed'`
+	if apply {
+		set = `idle_since = last_activity_at, idle_status = 'applied', end_time = last_activity_at`
+	}
+	query := `
+		UPDATE worklogs
+		SET ` + set + `
+		WHERE end_time IS NULL AND last_activity_at < $1
+			AND (idle_status IS NULL OR (idle_status = 'discarded' AND last_activity_at > idle_since))
+		RETURNING ` + worklogColumns
+
+	rows, err := r.db.QueryContext(ctx, query, inactiveSince)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var worklogs []models.Worklog
+
+	for rows.Next() {
+		w, err := scanWorklog(rows)
+		if err != nil {
+			return nil, err
+		}
+		worklogs = append(worklogs, *w)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return worklogs, nil
+}
+
+// ResolveIdle accepts or discards a pending idle proposal. Accepting ends the
+// worklog where the idle tail starts; discarding keeps the time. A worklog
+// that was stopped before the idle tail can't be accepted, as that would
+// lengthen it. With resumeAt, accepting a running worklog also starts a new
+// one for the same task at resumeAt, with the same note and tags, in the same
+// transaction.
+func (r *taskRepository) ResolveIdle(ctx context.Context, userId, worklogId int, accept bool, resumeAt *time.Time) (_ *models.Worklog, err error) {
+	ctx, end := begin(ctx, "taskRepository.ResolveIdle", r.timeouts.Query)
+	defer end(&err)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Блокируем запись, чтобы таймер не остановили между проверкой и обрезкой
+	var running bool
+	err = tx.QueryRowContext(ctx, `SELECT end_time IS NULL FROM worklogs WHERE id = $1 AND user_id = $2 FOR UPDATE`, worklogId, userId).Scan(&running)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWorklogNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	set, where := `idle_status = 'discarded'`, ``
+	if accept {
+		set, where = `idle_status = 'accepted', end_time = idle_since`, ` AND (end_time IS NULL OR end_time > idle_since)`
+	}
+	query := `
+		UPDATE worklogs
+		SET ` + set + `
+		WHERE id = $1 AND user_id = $2 AND idle_status = 'proposed'` + where + `
+		RETURNING ` + worklogColumns
+	w, err := scanWorklog(tx.QueryRowContext(ctx, query, worklogId, userId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoIdleProposal
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if accept && running && resumeAt != nil {
+		query = `
+			INSERT INTO worklogs (user_id, task_id, start_time, last_activity_at, note)
+			VALUES ($1, $2, $3, $3, $4)
+			RETURNING id
+		`
+		var resumedId int
+		if err := tx.QueryRowContext(ctx, query, userId, w.TaskID, *resumeAt, w.Note).Scan(&resumedId); err != nil {
+			return nil, err
+		}
+		query = `
+			INSERT INTO worklog_tags (worklog_id, tag_id)
+			SELECT $1, tag_id FROM worklog_tags WHERE worklog_id = $2
+		`
+		if _, err := tx.ExecContext(ctx, query, resumedId, worklogId); err != nil {
+			return nil, err
+		}
+	}
+	return w, tx.Commit()
+}
+
+func (r *taskRepository) CountOpenWorklogs(ctx context.Context) (_ int, err error) {
+	ctx, end := begin(ctx, "taskRepository.CountOpenWorklogs", r.timeouts.Query)
+	defer end(&err)
+
+	var count int
+	err = r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM worklogs WHERE end_time IS NULL").Scan(&count)
+	return count, err
+}
+
+func (r *taskRepository) CountPendingIdle(ctx context.Context) (_ int, err error) {
+	ctx, end := begin(ctx, "taskRepository.CountPendingIdle", r.timeouts.Query)
+	defer end(&err)
+
+	var count int
+	err = r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM worklogs WHERE idle_status = 'proposed'").Scan(&count)
+	return count, err
 }
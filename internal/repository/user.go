@@ -1,104 +1,264 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+	"github.com/lib/pq"
 )
 
 type UserRepository interface {
-	GetUsers(page, limit int, filters map[string]string) ([]models.User, int, error)
-	CreateUser(user *models.User) error
-	DeleteUser(userId string) error
-	UpdateUser(user *models.User) error
+	GetUsers(ctx context.Context, params models.UserListParams) (*models.UserList, error)
+	GetUserByID(ctx context.Context, userId int) (*models.User, error)
+	GetUserByPassport(ctx context.Context, passportNumber string) (*models.User, error)
+	CreateUser(ctx context.Context, user *models.User) error
+	DeleteUser(ctx context.Context, userId int) error
+	RestoreUser(ctx context.Context, userId int) error
+	PurgeUser(ctx context.Context, userId int) error
+	UpdateUser(ctx context.Context, user *models.User) error
 }
 
 type userRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	timeouts Timeouts
 }
 
-func NewUserRepository(db *sql.DB) UserRepository {
-	return &userRepository{db: db}
+func NewUserRepository(db *sql.DB, timeouts Timeouts) UserRepository {
+	return &userRepository{db: db, timeouts: timeouts}
 }
 
-func (r *userRepository) CreateUser(user *models.User) error {
+func (r *userRepository) CreateUser(ctx context.Context, user *models.User) (err error) {
+	ctx, end := begin(ctx, "userRepository.CreateUser", r.timeouts.Query)
+	defer end(&err)
+
 	query := `
 		INSERT INTO users (name, surname, patronymic, passport_number, address)
 		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, created_at;
 	`
-	err := r.db.QueryRow(query, user.Name, user.Surname, user.Patronymic, user.PassportNumber, user.Address).Scan(&user.ID, &user.CreatedAt)
-	return err
+	err = r.db.QueryRowContext(ctx, query, user.Name, user.Surname, user.Patronymic, user.PassportNumber, user.Address).Scan(&user.ID, &user.CreatedAt)
+	return mapUserWriteError(err)
 }
 
-func (r *userRepository) GetUsers(page, limit int, filters map[string]string) ([]models.User, int, error) {
-	var users []models.User
-	query := "SELECT id, name, surname, patronymic, passport_number, task_ids,created_at FROM users WHERE 1=1"
+func (r *userRepository) GetUsers(ctx context.Context, params models.UserListParams) (_ *models.UserList, err error) {
+	ctx, end := begin(ctx, "userRepository.GetUsers", r.timeouts.Query)
+	defer end(&err)
 
-	// Применение фильтров
-	args := []interface{}{}
-	name := ""
-	query += " AND (name LIKE $1 OR $1 = '')"
-	if nameq, ok := filters["name"]; ok {
-		nameq = "%" + nameq + "%"
-		name = nameq
+	order := strings.ToLower(params.Order)
+	if order == "" {
+		order = OrderAsc
+	}
+	if order != OrderAsc && order != OrderDesc {
+		return nil, ErrInvalidSort
 	}
-	args = append(args, name)
 
-	passportNumber := ""
-	query += " AND (passport_number = $2 OR $2 = '')"
-	if passportNumberQ, ok := filters["passportNumber"]; ok {
-		passportNumber = passportNumberQ
+	sort := params.Sort
+	if sort == "" {
+		sort = "id"
+		if params.Filter.Search != "" {
+			sort, order = SortRelevance, OrderDesc
+		}
 	}
-	args = append(args, passportNumber)
+
+	var q userQuery
+	q.applyFilter(params.Filter)
 
 	// Получение общего количества
-	var total int
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM (%s) AS count_table", query)
-	err := r.db.QueryRow(countQuery, args...).Scan(&total)
-	if err != nil {
-		return nil, 0, err
+	list := &models.UserList{}
+	countQuery := "SELECT COUNT(*) FROM users" + q.whereClause()
+	if err := r.db.QueryRowContext(ctx, countQuery, q.args...).Scan(&list.Total); err != nil {
+		return nil, err
+	}
+
+	// Сортировка и пагинация: по курсору, если он передан, иначе по номеру страницы
+	var orderBy string
+	var column userSortColumn
+	if sort == SortRelevance {
+		if params.Filter.Search == "" {
+			return nil, ErrInvalidSort
+		}
+		if params.Cursor != "" {
+			return nil, ErrInvalidCursor
+		}
+		orderBy = fmt.Sprintf("similarity(%s, %s) DESC, id ASC", userFullName, q.arg(params.Filter.Search))
+	} else {
+		var ok bool
+		column, ok = userSortColumns[sort]
+		if !ok {
+			return nil, ErrInvalidSort
+		}
+		orderBy = fmt.Sprintf("%s %s, id %s", column.expr, order, order)
+	}
+
+	pagination := ""
+	if params.Cursor != "" {
+		cursor, err := decodeUserCursor(params.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Sort != sort || cursor.Order != order {
+			return nil, ErrInvalidCursor
+		}
+		op := ">"
+		if order == OrderDesc {
+			op = "<"
+		}
+		q.where(fmt.Sprintf("(%s, id) %s (%s::%s, %s)", column.expr, op, q.arg(cursor.Value), column.cast, q.arg(cursor.ID)))
+	} else {
+		offset := (params.Page - 1) * params.Limit
+		pagination = " OFFSET " + q.arg(offset)
 	}
 
-	// Применение пагинации и получение данных
-	offset := (page - 1) * limit
-	query += (" LIMIT $3 OFFSET $4")
-	args = append(args, limit, offset)
+	// Запрашиваем на одну строку больше, чтобы узнать, есть ли следующая страница
+	query := "SELECT id, name, surname, patronymic, passport_number, address, COALESCE(team, ''), task_ids, created_at, deleted_at FROM users" +
+		q.whereClause() + " ORDER BY " + orderBy + " LIMIT " + q.arg(params.Limit+1) + pagination
 
-	rows, err := r.db.Query(query, args...) // Select fsf, fesfes From fsef where $3
+	rows, err := r.db.QueryContext(ctx, query, q.args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var user models.User
-		if err := rows.Scan(&user.ID, &user.Name, &user.Surname, &user.Patronymic, &user.PassportNumber, &user.TaskIDs, &user.CreatedAt); err != nil {
-			return nil, 0, err
+		var taskIds pq.Int64Array
+		if err := rows.Scan(&user.ID, &user.Name, &user.Surname, &user.Patronymic, &user.PassportNumber, &user.Address, &user.Team, &taskIds, &user.CreatedAt, &user.DeletedAt); err != nil {
+			return nil, err
+		}
+		// lib/pq сканирует массивы только в []int64
+		if taskIds != nil {
+			user.TaskIDs = make([]int, len(taskIds))
+			for i, id := range taskIds {
+				user.TaskIDs[i] = int(id)
+			}
 		}
-		users = append(users, user)
+		list.Users = append(list.Users, user)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
-	return users, total, nil
+	if len(list.Users) > params.Limit {
+		list.Users = list.Users[:params.Limit]
+		if sort != SortRelevance {
+			last := &list.Users[len(list.Users)-1]
+			list.NextCursor = encodeUserCursor(userCursor{Sort: sort, Order: order, Value: column.value(last), ID: last.ID})
+		}
+	}
+
+	return list, nil
+}
+
+func (r *userRepository) DeleteUser(ctx context.Context, userId int) (err error) {
+	ctx, end := begin(ctx, "userRepository.DeleteUser", r.timeouts.Query)
+	defer end(&err)
+
+	query := "UPDATE users SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL"
+	return r.execAffectingUser(ctx, query, userId)
+}
+
+func (r *userRepository) RestoreUser(ctx context.Context, userId int) (err error) {
+	ctx, end := begin(ctx, "userRepository.RestoreUser", r.timeouts.Query)
+	defer end(&err)
+
+	query := "UPDATE users SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL AND purged_at IS NULL"
+	return r.execAffectingUser(ctx, query, userId)
+}
+
+// PurgeUser anonymises the personal data of a user. The row itself is kept so
+// that worklogs referencing it still add up to the same totals.
+func (r *userRepository) PurgeUser(ctx context.Context, userId int) (err error) {
+	ctx, end := begin(ctx, "userRepository.PurgeUser", r.timeouts.Query)
+	defer end(&err)
+
+	query := `
+		UPDATE users
+		SET name = '', surname = '', patronymic = '', address = '',
+			passport_number = 'purged-' || id,
+			deleted_at = COALESCE(deleted_at, CURRENT_TIMESTAMP),
+			purged_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND purged_at IS NULL
+	`
+	return r.execAffectingUser(ctx, query, userId)
 }
 
-func (r *userRepository) DeleteUser(userId string) error {
-	query := "DELETE FROM users WHERE id = $1"
-	_, err := r.db.Exec(query, userId)
-	return err
+// execAffectingUser runs a statement that targets a single user and reports
+// ErrUserNotFound when no row matched.
+func (r *userRepository) execAffectingUser(ctx context.Context, query string, userId int) error {
+	result, err := r.db.ExecContext(ctx, query, userId)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
 }
 
-func (r *userRepository) UpdateUser(user *models.User) error {
+func (r *userRepository) UpdateUser(ctx context.Context, user *models.User) (err error) {
+	ctx, end := begin(ctx, "userRepository.UpdateUser", r.timeouts.Query)
+	defer end(&err)
+
 	query := `
 		UPDATE users 
-		SET name = $1, surname = $2, patronymic = $3, passport_number = $4, address = $5
-		WHERE id = $6
+		SET name = $1, surname = $2, patronymic = $3, passport_number = $4, address = $5, team = NULLIF($6, '')
+		WHERE id = $7 AND deleted_at IS NULL
+	`
+	result, err := r.db.ExecContext(ctx, query, user.Name, user.Surname, user.Patronymic, user.PassportNumber, user.Address, user.Team, user.ID)
+	if err != nil {
+		return mapUserWriteError(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) GetUserByID(ctx context.Context, userId int) (_ *models.User, err error) {
+	ctx, end := begin(ctx, "userRepository.GetUserByID", r.timeouts.Query)
+	defer end(&err)
+
+	query := `
+		SELECT id, name, surname, patronymic, passport_number, address, COALESCE(team, ''), created_at, deleted_at
+		FROM users
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	return r.getUser(ctx, query, userId)
+}
+
+func (r *userRepository) GetUserByPassport(ctx context.Context, passportNumber string) (_ *models.User, err error) {
+	ctx, end := begin(ctx, "userRepository.GetUserByPassport", r.timeouts.Query)
+	defer end(&err)
+
+	query := `
+		SELECT id, name, surname, patronymic, passport_number, address, COALESCE(team, ''), created_at, deleted_at
+		FROM users
+		WHERE passport_number = $1
 	`
-	_, err := r.db.Exec(query, user.Name, user.Surname, user.Patronymic, user.PassportNumber, user.Address, user.ID)
-	return err
+	return r.getUser(ctx, query, passportNumber)
+}
+
+func (r *userRepository) getUser(ctx context.Context, query string, args ...any) (*models.User, error) {
+	var user models.User
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&user.ID, &user.Name, &user.Surname, &user.Patronymic, &user.PassportNumber, &user.Address, &user.Team, &user.CreatedAt, &user.DeletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
 }
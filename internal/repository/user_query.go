@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+)
+
+var (
+	ErrInvalidSort   = errors.New("invalid sort")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+const (
+	SortRelevance = "relevance"
+	OrderAsc      = "asc"
+	OrderDesc     = "desc"
+)
+
+// userFullName must match the expression of users_full_name_trgm_idx so the
+// trigram index can be used.
+const userFullName = `(COALESCE(surname, '') || ' ' || COALESCE(name, '') || ' ' || COALESCE(patronymic, ''))`
+
+type userSortColumn struct {
+	expr string
+	cast string
+	// value returns the sort key of a user as it is stored in a cursor.
+	value func(u *models.User) string
+}
+
+var userSortColumns = map[string]userSortColumn{
+	"id":             {"id", "int", func(u *models.User) string { return strconv.Itoa(u.ID) }},
+	"name":           {"COALESCE(name, '')", "text", func(u *models.User) string { return u.Name }},
+	"surname":        {"COALESCE(surname, '')", "text", func(u *models.User) string { return u.Surname }},
+	"patronymic":     {"COALESCE(patronymic, '')", "text", func(u *models.User) string { return u.Patronymic }},
+	"passportNumber": {"passport_number", "text", func(u *models.User) string { return u.PassportNumber }},
+	"address":        {"COALESCE(address, '')", "text", func(u *models.User) string { return u.Address }},
+	"createdAt":      {"created_at", "timestamptz", func(u *models.User) string { return u.CreatedAt.Format(time.RFC3339Nano) }},
+}
+
+// userCursor is the decoded form of the opaque cursor handed out by GetUsers.
+// It remembers the sort it was issued for, so it can't be replayed against a
+// different ordering.
+type userCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+func encodeUserCursor(cursor userCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeUserCursor(raw string) (userCursor, error) {
+	var cursor userCursor
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// userQuery accumulates WHERE conditions and their positional arguments.
+type userQuery struct {
+	conditions []string
+	args       []any
+}
+
+func (q *userQuery) arg(value any) string {
+	q.args = append(q.args, value)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+func (q *userQuery) where(condition string) {
+	q.conditions = append(q.conditions, condition)
+}
+
+func (q *userQuery) whereClause() string {
+	if len(q.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(q.conditions, " AND ")
+}
+
+func (q *userQuery) applyFilter(filter models.UserFilter) {
+	contains := map[string]string{
+		"name":       filter.Name,
+		"surname":    filter.Surname,
+		"patronymic": filter.Patronymic,
+		"address":    filter.Address,
+	}
+	for _, column := range []string{"name", "surname", "patronymic", "address"} {
+		if value := contains[column]; value != "" {
+			q.where(fmt.Sprintf("%s ILIKE %s", column, q.arg("%"+escapeLike(value)+"%")))
+		}
+	}
+
+	if filter.PassportNumber != "" {
+		q.where("passport_number = " + q.arg(filter.PassportNumber))
+	}
+	if filter.Search != "" {
+		q.where(userFullName + " % " + q.arg(filter.Search))
+	}
+	if filter.CreatedFrom != nil {
+		q.where("created_at >= " + q.arg(*filter.CreatedFrom))
+	}
+	if filter.CreatedTo != nil {
+		q.where("created_at <= " + q.arg(*filter.CreatedTo))
+	}
+
+	// Удалённые пользователи скрыты, если не запрошены явно
+	switch {
+	case filter.Active != nil && *filter.Active:
+		q.where("deleted_at IS NULL")
+	case filter.Active != nil:
+		q.where("deleted_at IS NOT NULL")
+	case !filter.IncludeDeleted:
+		q.where("deleted_at IS NULL")
+	}
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
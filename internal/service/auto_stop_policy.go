@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+	"github.com/KarmaBeLike/time-tracker-api/internal/repository"
+	"github.com/KarmaBeLike/time-tracker-api/internal/tracing"
+)
+
+type AutoStopPolicyService interface {
+	GetPolicies(ctx context.Context) ([]models.AutoStopPolicy, error)
+	SaveUserPolicy(ctx context.Context, userId int, policy models.AutoStopPolicy) (*models.AutoStopPolicy, error)
+	SaveTeamPolicy(ctx context.Context, team string, policy models.AutoStopPolicy) (*models.AutoStopPolicy, error)
+	DeleteUserPolicy(ctx context.Context, userId int) error
+	DeleteTeamPolicy(ctx context.Context, team string) error
+}
+
+type autoStopPolicyService struct {
+	policyRepo repository.AutoStopPolicyRepository
+}
+
+func NewAutoStopPolicyService(policyRepo repository.AutoStopPolicyRepository) AutoStopPolicyService {
+	return &autoStopPolicyService{policyRepo: policyRepo}
+}
+
+func (s *autoStopPolicyService) GetPolicies(ctx context.Context) (_ []models.AutoStopPolicy, err error) {
+	ctx, span := tracing.Start(ctx, "autoStopPolicyService.GetPolicies")
+	defer func() { tracing.End(span, err) }()
+
+	policies, err := s.policyRepo.GetPolicies(ctx)
+	return policies, mapRepoError(err)
+}
+
+func (s *autoStopPolicyService) SaveUserPolicy(ctx context.Context, userId int, policy models.AutoStopPolicy) (_ *models.AutoStopPolicy, err error) {
+	ctx, span := tracing.Start(ctx, "autoStopPolicyService.SaveUserPolicy")
+	defer func() { tracing.End(span, err) }()
+
+	policy.UserID = &userId
+	policy.Team = ""
+	if err := s.policyRepo.SavePolicy(ctx, &policy); err != nil {
+		return nil, mapRepoError(err)
+	}
+	return &policy, nil
+}
+
+func (s *autoStopPolicyService) SaveTeamPolicy(ctx context.Context, team string, policy models.AutoStopPolicy) (_ *models.AutoStopPolicy, err error) {
+	ctx, span := tracing.Start(ctx, "autoStopPolicyService.SaveTeamPolicy")
+	defer func() { tracing.End(span, err) }()
+
+	policy.UserID = nil
+	policy.Team = team
+	if err := s.policyRepo.SavePolicy(ctx, &policy); err != nil {
+		return nil, mapRepoError(err)
+	}
+	return &policy, nil
+}
+
+func (s *autoStopPolicyService) DeleteUserPolicy(ctx context.Context, userId int) (err error) {
+	ctx, span := tracing.Start(ctx, "autoStopPolicyService.DeleteUserPolicy")
+	defer func() { tracing.End(span, err) }()
+
+	return mapRepoError(s.policyRepo.DeleteUserPolicy(ctx, userId))
+}
+
+func (s *autoStopPolicyService) DeleteTeamPolicy(ctx context.Context, team string) (err error) {
+	ctx, span := tracing.Start(ctx, "autoStopPolicyService.DeleteTeamPolicy")
+	defer func() { tracing.End(span, err) }()
+
+	return mapRepoError(s.policyRepo.DeleteTeamPolicy(ctx, team))
+}
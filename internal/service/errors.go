@@ -0,0 +1,143 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/repository"
+)
+
+// ErrorKind classifies a domain error. The HTTP layer maps kinds to status
+// codes; the service layer never deals with HTTP directly.
+type ErrorKind int
+
+const (
+	KindInternal ErrorKind = iota
+	KindValidation
+	KindNotFound
+	KindConflict
+	KindUnavailable
+	KindUnauthorized
+)
+
+// Error is a domain error with a stable, machine-readable code. Codes are part
+// of the public API and must not change once released.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Err     error
+	// Fields lists the offending fields of a validation error.
+	Fields []FieldError
+}
+
+// FieldError describes why a single request field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports errors with the same code as equal, so a wrapped copy of a
+// sentinel still matches it with errors.Is.
+func (e *Error) Is(target error) bool {
+	var t *Error
+	return errors.As(target, &t) && t.Code == e.Code
+}
+
+// Wrap returns a copy of e that carries err as its cause.
+func (e *Error) Wrap(err error) *Error {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
+
+func NewValidationError(code, message string) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message}
+}
+
+// NewFieldsError returns a validation error listing the rejected fields.
+func NewFieldsError(fields []FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: "validation_failed", Message: "request validation failed", Fields: fields}
+}
+
+func NewNotFoundError(code, message string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message}
+}
+
+func NewConflictError(code, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+func NewUnavailableError(code, message string) *Error {
+	return &Error{Kind: KindUnavailable, Code: code, Message: message}
+}
+
+func NewUnauthorizedError(code, message string) *Error {
+	return &Error{Kind: KindUnauthorized, Code: code, Message: message}
+}
+
+var (
+	ErrUserNotFound        = NewNotFoundError("user_not_found", "user not found")
+	ErrDeletedUserNotFound = NewNotFoundError("deleted_user_not_found", "deleted user not found")
+	ErrTaskNotFound        = NewNotFoundError("task_not_found", "user or task not found")
+	ErrTimerNotRunning     = NewNotFoundError("timer_not_running", "no running timer for this task")
+	ErrPassportRequired    = NewValidationError("passport_required", "passport number can't be empty")
+	ErrPassportTaken       = NewConflictError("passport_taken", "passport number is already taken")
+	ErrInvalidPassport     = NewValidationError("invalid_passport", `passport number must look like "1234 567890"`)
+	ErrPersonNotFound      = NewValidationError("person_not_found", "no person found for this passport number")
+	ErrPeopleAPIDown       = NewUnavailableError("people_api_unavailable", "people data source is unavailable")
+	ErrInvalidSort         = NewValidationError("invalid_sort", "invalid sort or order")
+	ErrInvalidCursor       = NewValidationError("invalid_cursor", "invalid cursor")
+	ErrPolicyNotFound      = NewNotFoundError("policy_not_found", "auto-stop policy not found")
+	ErrWorklogNotFound     = NewNotFoundError("worklog_not_found", "worklog not found")
+	ErrNoIdleProposal      = NewConflictError("no_idle_proposal", "worklog has no pending idle proposal")
+	ErrHeartbeatInFuture   = NewValidationError("heartbeat_in_future", "activity time can't be in the future")
+	ErrQueryTimeout        = NewUnavailableError("query_timeout", "the query took too long and was cancelled")
+	ErrTagNotFound         = NewNotFoundError("tag_not_found", "tag not found")
+	ErrTagNameTaken        = NewConflictError("tag_name_taken", "tag name is already taken")
+)
+
+// mapRepoError translates repository errors into domain errors. Unknown errors
+// are returned unchanged and end up as internal errors.
+func mapRepoError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, repository.ErrUserNotFound):
+		return ErrUserNotFound
+	case errors.Is(err, repository.ErrPassportExists):
+		return ErrPassportTaken
+	case errors.Is(err, repository.ErrTaskNotFound):
+		return ErrTaskNotFound
+	case errors.Is(err, repository.ErrNoRunningTask):
+		return ErrTimerNotRunning
+	case errors.Is(err, repository.ErrInvalidSort):
+		return ErrInvalidSort
+	case errors.Is(err, repository.ErrInvalidCursor):
+		return ErrInvalidCursor
+	case errors.Is(err, repository.ErrPolicyNotFound):
+		return ErrPolicyNotFound
+	case errors.Is(err, repository.ErrWorklogNotFound):
+		return ErrWorklogNotFound
+	case errors.Is(err, repository.ErrNoIdleProposal):
+		return ErrNoIdleProposal
+	case errors.Is(err, repository.ErrTagNotFound):
+		return ErrTagNotFound
+	case errors.Is(err, repository.ErrTagExists):
+		return ErrTagNameTaken
+	case repository.IsTimeout(err):
+		return ErrQueryTimeout.Wrap(err)
+	default:
+		return err
+	}
+}
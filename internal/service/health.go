@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/buildinfo"
+)
+
+// HealthCheck is a named readiness dependency.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+const (
+	CheckFailed  = "check_failed"
+	CheckTimeout = "check_timeout"
+)
+
+// CheckResult is the outcome of a single HealthCheck. Probes are public, so
+// a failure is only reported by a stable code; Err is for the log.
+type CheckResult struct {
+	Status string `json:"status"`
+	Code   string `json:"code,omitempty"`
+	Err    error  `json:"-"`
+}
+
+// Readiness is the combined outcome of all readiness checks.
+type Readiness struct {
+	Ready  bool                   `json:"ready"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// VersionInfo describes the running binary and the database schema.
+type VersionInfo struct {
+	buildinfo.Info
+	SchemaVersion string `json:"schemaVersion"`
+}
+
+type HealthService interface {
+	Ready(ctx context.Context) Readiness
+	Version(ctx context.Context) (VersionInfo, error)
+}
+
+type healthService struct {
+	checks        []HealthCheck
+	schemaVersion func(ctx context.Context) (string, error)
+}
+
+func NewHealthService(schemaVersion func(ctx context.Context) (string, error), checks ...HealthCheck) HealthService {
+	return &healthService{checks: checks, schemaVersion: schemaVersion}
+}
+
+// Ready runs all checks concurrently.
+func (s *healthService) Ready(ctx context.Context) Readiness {
+	results := make([]error, len(s.checks))
+	var wg sync.WaitGroup
+	for i, check := range s.checks {
+		wg.Add(1)
+		go func(i int, check HealthCheck) {
+			defer wg.Done()
+			results[i] = check.Check(ctx)
+		}(i, check)
+	}
+	wg.Wait()
+
+	readiness := Readiness{Ready: true, Checks: make(map[string]CheckResult, len(s.checks))}
+	for i, check := range s.checks {
+		if err := results[i]; err != nil {
+			readiness.Ready = false
+			code := CheckFailed
+			if errors.Is(err, context.DeadlineExceeded) {
+				code = CheckTimeout
+			}
+			readiness.Checks[check.Name] = CheckResult{Status: "fail", Code: code, Err: err}
+			continue
+		}
+		readiness.Checks[check.Name] = CheckResult{Status: "ok"}
+	}
+	return readiness
+}
+
+func (s *healthService) Version(ctx context.Context) (VersionInfo, error) {
+	version, err := s.schemaVersion(ctx)
+	if err != nil {
+		return VersionInfo{}, err
+	}
+	return VersionInfo{Info: buildinfo.Get(), SchemaVersion: version}, nil
+}
+
+// CachedCheck remembers the result of check for ttl, so that frequent probes
+// don't hammer a slow or rate-limited dependency.
+func CachedCheck(check func(ctx context.Context) error, ttl time.Duration) func(ctx context.Context) error {
+	var mu sync.Mutex
+	var checkedAt time.Time
+	var last error
+
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !checkedAt.IsZero() && time.Since(checkedAt) < ttl {
+			return last
+		}
+		last = check(ctx)
+		checkedAt = time.Now()
+		return last
+	}
+}
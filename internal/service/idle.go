@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/clock"
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+	"github.com/KarmaBeLike/time-tracker-api/internal/repository"
+	"github.com/KarmaBeLike/time-tracker-api/internal/tracing"
+	"github.com/KarmaBeLike/time-tracker-api/pkg/logger"
+)
+
+// IdleDetector finds running timers whose clients stopped sending heartbeats
+// and either proposes trimming their idle tail or trims it right away.
+type IdleDetector struct {
+	taskRepo  repository.TaskRepository
+	threshold time.Duration
+	apply     bool
+	interval  time.Duration
+	clock     clock.Clock
+}
+
+// NewIdleDetector creates a detector that treats timers without a heartbeat
+// for threshold as idle. With apply the idle tail is cut off automatically,
+// otherwise it waits for the user to accept or discard it. Run takes the
+// current time from clock.
+func NewIdleDetector(taskRepo repository.TaskRepository, threshold time.Duration, apply bool, interval time.Duration, clock clock.Clock) *IdleDetector {
+	return &IdleDetector{
+		taskRepo:  taskRepo,
+		threshold: threshold,
+		apply:     apply,
+		interval:  interval,
+		clock:     clock,
+	}
+}
+
+// Run checks for idle timers periodically until ctx is cancelled.
+func (d *IdleDetector) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := d.Detect(ctx, d.clock.Now()); err != nil {
+				logger.PrintError(err, map[string]any{"job": "idle-detection"})
+			}
+		}
+	}
+}
+
+// Detect flags the timers that are idle as of now and returns them.
+func (d *IdleDetector) Detect(ctx context.Context, now time.Time) (_ []models.Worklog, err error) {
+	ctx, span := tracing.Start(ctx, "IdleDetector.Detect")
+	defer func() { tracing.End(span, err) }()
+
+	worklogs, err := d.taskRepo.DetectIdle(ctx, now.Add(-d.threshold), d.apply)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, w := range worklogs {
+		logger.PrintInfo("Idle time detected", map[string]any{
+			"worklogId":  w.ID,
+			"userId":     w.UserID,
+			"idleSince":  w.IdleSince,
+			"idleStatus": w.IdleStatus,
+		})
+	}
+
+	return worklogs, nil
+}
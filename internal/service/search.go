@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+	"github.com/KarmaBeLike/time-tracker-api/internal/repository"
+	"github.com/KarmaBeLike/time-tracker-api/internal/tracing"
+)
+
+// DefaultSearchLimit is the number of hits returned when no limit is given.
+const DefaultSearchLimit = 20
+
+type SearchService interface {
+	Search(ctx context.Context, params models.SearchParams) ([]models.SearchHit, error)
+}
+
+type searchService struct {
+	searchRepo repository.SearchRepository
+}
+
+func NewSearchService(searchRepo repository.SearchRepository) SearchService {
+	return &searchService{searchRepo: searchRepo}
+}
+
+func (s *searchService) Search(ctx context.Context, params models.SearchParams) (_ []models.SearchHit, err error) {
+	ctx, span := tracing.Start(ctx, "searchService.Search")
+	defer func() { tracing.End(span, err) }()
+
+	params.Query = strings.TrimSpace(params.Query)
+	if params.Query == "" {
+		return nil, nil
+	}
+	if params.Limit <= 0 {
+		params.Limit = DefaultSearchLimit
+	}
+
+	hits, err := s.searchRepo.Search(ctx, params)
+	return hits, mapRepoError(err)
+}
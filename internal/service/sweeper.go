@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/clock"
+	"github.com/KarmaBeLike/time-tracker-api/internal/external"
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+	"github.com/KarmaBeLike/time-tracker-api/internal/repository"
+	"github.com/KarmaBeLike/time-tracker-api/internal/tracing"
+	"github.com/KarmaBeLike/time-tracker-api/pkg/logger"
+)
+
+// TimerSweeper stops timers that have been running for longer than the
+// auto-stop policy of their owner allows.
+type TimerSweeper struct {
+	taskRepo      repository.TaskRepository
+	notifier      external.Notifier
+	defaultPolicy models.AutoStopPolicy
+	interval      time.Duration
+	clock         clock.Clock
+}
+
+// NewTimerSweeper creates a sweeper that checks every interval and applies
+// defaultPolicy to users without a policy of their own or of their team. Run
+// takes the current time from clock.
+func NewTimerSweeper(taskRepo repository.TaskRepository, notifier external.Notifier, defaultPolicy models.AutoStopPolicy, interval time.Duration, clock clock.Clock) *TimerSweeper {
+	return &TimerSweeper{
+		taskRepo:      taskRepo,
+		notifier:      notifier,
+		defaultPolicy: defaultPolicy,
+		interval:      interval,
+		clock:         clock,
+	}
+}
+
+// Run sweeps periodically until ctx is cancelled.
+func (s *TimerSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Sweep(ctx, s.clock.Now()); err != nil {
+				logger.PrintError(err, map[string]any{"job": "auto-stop"})
+			}
+		}
+	}
+}
+
+// Sweep stops every abandoned timer as of now and returns the stopped
+// worklogs. A failure to stop or notify about one timer doesn't prevent the
+// others from being processed.
+func (s *TimerSweeper) Sweep(ctx context.Context, now time.Time) (_ []models.Worklog, err error) {
+	ctx, span := tracing.Start(ctx, "TimerSweeper.Sweep")
+	defer func() { tracing.End(span, err) }()
+
+	open, err := s.taskRepo.GetOpenWorklogs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var stopped []models.Worklog
+	for _, w := range open {
+		policy := s.defaultPolicy
+		if w.Policy != nil {
+			policy = *w.Policy
+		}
+		if now.Sub(w.StartTime) <= time.Duration(policy.MaxDuration) {
+			continue
+		}
+
+		end := autoStopTime(w.Worklog, policy, now)
+		err := s.taskRepo.AutoStopWorklog(ctx, w.ID, end)
+		if errors.Is(err, repository.ErrNoRunningTask) {
+			// Остановлен пользователем между выборкой и обновлением
+			continue
+		}
+		if err != nil {
+			logger.PrintError(err, map[string]any{"job": "auto-stop", "worklogId": w.ID})
+			continue
+		}
+
+		w.EndTime = &end
+		w.AutoStopped = true
+		stopped = append(stopped, w.Worklog)
+
+		notice := models.AutoStopNotice{
+			UserID:    w.UserID,
+			WorklogID: w.ID,
+			TaskID:    w.TaskID,
+			StartTime: w.StartTime,
+			EndTime:   end,
+			StopAt:    policy.StopAt,
+		}
+		if err := s.notifier.NotifyAutoStopped(ctx, notice); err != nil {
+			logger.PrintError(err, map[string]any{"job": "auto-stop", "worklogId": w.ID, "userId": w.UserID})
+		}
+	}
+
+	return stopped, nil
+}
+
+// autoStopTime is where an abandoned worklog is cut off: at the user's last
+// activity if the policy asks for it and there was any, otherwise after the
+// policy cap. It is never later than now.
+func autoStopTime(w models.Worklog, policy models.AutoStopPolicy, now time.Time) time.Time {
+	end := w.StartTime.Add(time.Duration(policy.Cap))
+	if policy.StopAt == models.StopAtLastActivity && w.LastActivityAt != nil && w.LastActivityAt.After(w.StartTime) {
+		end = *w.LastActivityAt
+	}
+	if end.After(now) {
+		end = now
+	}
+	return end
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/KarmaBeLike/time-tracker-api/internal/models"
+	"github.com/KarmaBeLike/time-tracker-api/internal/repository"
+	"github.com/KarmaBeLike/time-tracker-api/internal/tracing"
+)
+
+// DefaultTagColor is the color of tags created without one.
+const DefaultTagColor = "#9e9e9e"
+
+type TagService interface {
+	GetTags(ctx context.Context, userId int) ([]models.Tag, error)
+	CreateTag(ctx context.Context, userId int, tag models.Tag) (*models.Tag, error)
+	UpdateTag(ctx context.Context, userId, tagId int, tag models.Tag) (*models.Tag, error)
+	DeleteTag(ctx context.Context, userId, tagId int) error
+	SetWorklogTags(ctx context.Context, userId, worklogId int, tagIds []int) ([]models.Tag, error)
+	SetTaskTags(ctx context.Context, userId, taskId int, tagIds []int) ([]models.Tag, error)
+}
+
+type tagService struct {
+	tagRepo repository.TagRepository
+}
+
+func NewTagService(tagRepo repository.TagRepository) TagService {
+	return &tagService{tagRepo: tagRepo}
+}
+
+func (s *tagService) GetTags(ctx context.Context, userId int) (_ []models.Tag, err error) {
+	ctx, span := tracing.Start(ctx, "tagService.GetTags")
+	defer func() { tracing.End(span, err) }()
+
+	tags, err := s.tagRepo.GetTags(ctx, userId)
+	return tags, mapRepoError(err)
+}
+
+func (s *tagService) CreateTag(ctx context.Context, userId int, tag models.Tag) (_ *models.Tag, err error) {
+	ctx, span := tracing.Start(ctx, "tagService.CreateTag")
+	defer func() { tracing.End(span, err) }()
+
+	tag.UserID = userId
+	tag.Color = tagColor(tag.Color)
+	if err := s.tagRepo.CreateTag(ctx, &tag); err != nil {
+		return nil, mapRepoError(err)
+	}
+	return &tag, nil
+}
+
+func (s *tagService) UpdateTag(ctx context.Context, userId, tagId int, tag models.Tag) (_ *models.Tag, err error) {
+	ctx, span := tracing.Start(ctx, "tagService.UpdateTag")
+	defer func() { tracing.End(span, err) }()
+
+	tag.ID = tagId
+	tag.UserID = userId
+	tag.Color = tagColor(tag.Color)
+	if err := s.tagRepo.UpdateTag(ctx, &tag); err != nil {
+		return nil, mapRepoError(err)
+	}
+	return &tag, nil
+}
+
+func (s *tagService) DeleteTag(ctx context.Context, userId, tagId int) (err error) {
+	ctx, span := tracing.Start(ctx, "tagService.DeleteTag")
+	defer func() { tracing.End(span, err) }()
+
+	return mapRepoError(s.tagRepo.DeleteTag(ctx, userId, tagId))
+}
+
+// SetWorklogTags replaces the tags of a worklog of the user.
+func (s *tagService) SetWorklogTags(ctx context.Context, userId, worklogId int, tagIds []int) (_ []models.Tag, err error) {
+	ctx, span := tracing.Start(ctx, "tagService.SetWorklogTags")
+	defer func() { tracing.End(span, err) }()
+
+	tags, err := s.tagRepo.SetWorklogTags(ctx, userId, worklogId, tagIds)
+	return tags, mapRepoError(err)
+}
+
+// SetTaskTags replaces the tags the user has put on a task. They apply to
+// every worklog of the user on the task.
+func (s *tagService) SetTaskTags(ctx context.Context, userId, taskId int, tagIds []int) (_ []models.Tag, err error) {
+	ctx, span := tracing.Start(ctx, "tagService.SetTaskTags")
+	defer func() { tracing.End(span, err) }()
+
+	tags, err := s.tagRepo.SetTaskTags(ctx, userId, taskId, tagIds)
+	return tags, mapRepoError(err)
+}
+
+// tagColor normalizes a hex color as the database stores it.
+func tagColor(color string) string {
+	if color == "" {
+		return DefaultTagColor
+	}
+	return strings.ToLower(color)
+}
@@ -1,34 +1,138 @@
 package service
 
 import (
+	"context"
 	"time"
 
+	"github.com/KarmaBeLike/time-tracker-api/internal/clock"
 	"github.com/KarmaBeLike/time-tracker-api/internal/models"
 	"github.com/KarmaBeLike/time-tracker-api/internal/repository"
+	"github.com/KarmaBeLike/time-tracker-api/internal/tracing"
 )
 
 type TaskService interface {
-	GetWorklogs(userId int, startDate, endDate string) ([]models.Task, error)
-	StartTask(userId, taskId int) error
-	StopTask(userId, taskId int) error
+	GetWorklogs(ctx context.Context, userId int, startDate, endDate string, tags []string) ([]models.Task, error)
+	GetTagTotals(ctx context.Context, userId int, startDate, endDate string, tags []string) ([]models.TagTotal, error)
+	StartTask(ctx context.Context, userId, taskId int, note string, tagIds []int) (time.Time, error)
+	StopTask(ctx context.Context, userId, taskId int, note string) (time.Time, error)
+	GetRunningTimers(ctx context.Context, userId int) ([]models.Worklog, error)
+	Heartbeat(ctx context.Context, userId, taskId int, at *time.Time) (*models.Worklog, error)
+	ResolveIdle(ctx context.Context, userId, worklogId int, accept, resume bool) (*models.Worklog, error)
 }
 
 type taskService struct {
 	taskRepo repository.TaskRepository
+	clock    clock.Clock
 }
 
-func NewTaskService(taskRepo repository.TaskRepository) TaskService {
-	return &taskService{taskRepo: taskRepo}
+func NewTaskService(taskRepo repository.TaskRepository, clock clock.Clock) TaskService {
+	return &taskService{taskRepo: taskRepo, clock: clock}
 }
 
-func (s *taskService) GetWorklogs(userId int, startDate, endDate string) ([]models.Task, error) {
-	return s.taskRepo.GetWorklogs(userId, startDate, endDate)
+// now returns the current time as the database stores it, so that the
+// timestamps reported to callers match the persisted ones.
+func (s *taskService) now() time.Time {
+	// Postgres хранит время с точностью до микросекунд
+	return s.clock.Now().Truncate(time.Microsecond)
 }
 
-func (s *taskService) StartTask(userId, taskId int) error {
-	return s.taskRepo.StartTask(userId, taskId, time.Now())
+// GetWorklogs returns the time a user spent on each task within the period,
+// counting only worklogs with one of the tags if any are given.
+func (s *taskService) GetWorklogs(ctx context.Context, userId int, startDate, endDate string, tags []string) (_ []models.Task, err error) {
+	ctx, span := tracing.Start(ctx, "taskService.GetWorklogs")
+	defer func() { tracing.End(span, err) }()
+
+	startDate, endDate = reportPeriod(startDate, endDate)
+	tasks, err := s.taskRepo.GetWorklogs(ctx, userId, startDate, endDate, tags)
+	return tasks, mapRepoError(err)
+}
+
+// GetTagTotals is GetWorklogs grouped by tag instead of task.
+func (s *taskService) GetTagTotals(ctx context.Context, userId int, startDate, endDate string, tags []string) (_ []models.TagTotal, err error) {
+	ctx, span := tracing.Start(ctx, "taskService.GetTagTotals")
+	defer func() { tracing.End(span, err) }()
+
+	startDate, endDate = reportPeriod(startDate, endDate)
+	totals, err := s.taskRepo.GetTagTotals(ctx, userId, startDate, endDate, tags)
+	return totals, mapRepoError(err)
+}
+
+// reportPeriod replaces missing dates with open bounds.
+func reportPeriod(startDate, endDate string) (string, string) {
+	if startDate == "" {
+		startDate = "-infinity"
+	}
+	if endDate == "" {
+		endDate = "infinity"
+	}
+	return startDate, endDate
 }
 
-func (s *taskService) StopTask(userId, taskId int) error {
-	return s.taskRepo.StopTask(userId, taskId, time.Now())
+// StartTask starts a timer with a note and tags of the user and returns its
+// start time.
+func (s *taskService) StartTask(ctx context.Context, userId, taskId int, note string, tagIds []int) (_ time.Time, err error) {
+	ctx, span := tracing.Start(ctx, "taskService.StartTask")
+	defer func() { tracing.End(span, err) }()
+
+	now := s.now()
+	if err := s.taskRepo.StartTask(ctx, userId, taskId, now, note, tagIds); err != nil {
+		return time.Time{}, mapRepoError(err)
+	}
+	return now, nil
+}
+
+// StopTask stops the running timers of a task and returns their end time.
+// The note is added to the one given at the start.
+func (s *taskService) StopTask(ctx context.Context, userId, taskId int, note string) (_ time.Time, err error) {
+	ctx, span := tracing.Start(ctx, "taskService.StopTask")
+	defer func() { tracing.End(span, err) }()
+
+	now := s.now()
+	if err := s.taskRepo.StopTask(ctx, userId, taskId, now, note); err != nil {
+		return time.Time{}, mapRepoError(err)
+	}
+	return now, nil
+}
+
+func (s *taskService) GetRunningTimers(ctx context.Context, userId int) (_ []models.Worklog, err error) {
+	ctx, span := tracing.Start(ctx, "taskService.GetRunningTimers")
+	defer func() { tracing.End(span, err) }()
+
+	worklogs, err := s.taskRepo.GetRunningWorklogs(ctx, userId)
+	return worklogs, mapRepoError(err)
+}
+
+// Heartbeat records activity on the running timer at the given time, or now
+// if at is nil.
+func (s *taskService) Heartbeat(ctx context.Context, userId, taskId int, at *time.Time) (_ *models.Worklog, err error) {
+	ctx, span := tracing.Start(ctx, "taskService.Heartbeat")
+	defer func() { tracing.End(span, err) }()
+
+	now := s.now()
+	if at == nil {
+		at = &now
+	}
+	if at.After(now) {
+		return nil, ErrHeartbeatInFuture
+	}
+	worklog, err := s.taskRepo.RecordActivity(ctx, userId, taskId, *at)
+	return worklog, mapRepoError(err)
+}
+
+// ResolveIdle accepts or discards the idle proposal of a worklog. When an
+// accepted worklog was still running and resume is set, a new timer is
+// started for the same task, with the same note and tags, so the user keeps
+// tracking from now on.
+func (s *taskService) ResolveIdle(ctx context.Context, userId, worklogId int, accept, resume bool) (_ *models.Worklog, err error) {
+	ctx, span := tracing.Start(ctx, "taskService.ResolveIdle")
+	defer func() { tracing.End(span, err) }()
+
+	// Обрезка и новый таймер сохраняются вместе в репозитории
+	var resumeAt *time.Time
+	if accept && resume {
+		now := s.now()
+		resumeAt = &now
+	}
+	worklog, err := s.taskRepo.ResolveIdle(ctx, userId, worklogId, accept, resumeAt)
+	return worklog, mapRepoError(err)
 }
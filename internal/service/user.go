@@ -1,34 +1,59 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"regexp"
+
 	"github.com/KarmaBeLike/time-tracker-api/internal/external"
 	"github.com/KarmaBeLike/time-tracker-api/internal/models"
 	repositories "github.com/KarmaBeLike/time-tracker-api/internal/repository"
+	"github.com/KarmaBeLike/time-tracker-api/internal/tracing"
 )
 
+// PassportPattern is the "series number" format used by the People API,
+// e.g. "1234 567890".
+var PassportPattern = regexp.MustCompile(`^\d{4} \d{6}$`)
+
 type UserService interface {
-	GetUsers(page, limit int, filters map[string]string) ([]models.User, int, error)
-	CreateUser(passportNumber string) (*models.User, error)
-	DeleteUser(userId string) error
-	UpdateUser(user *models.User) error
+	GetUsers(ctx context.Context, params models.UserListParams) (*models.UserList, error)
+	CreateUser(ctx context.Context, passportNumber string) (*models.User, error)
+	DeleteUser(ctx context.Context, userId int) error
+	RestoreUser(ctx context.Context, userId int) error
+	PurgeUser(ctx context.Context, userId int) error
+	UpdateUser(ctx context.Context, user *models.User) error
+	PatchUser(ctx context.Context, userId int, patch models.UserPatch) (*models.User, error)
 }
 
 type userService struct {
-	userRepo        repositories.UserRepository
-	peopleAPIClient *external.PeopleAPIClient
+	userRepo       repositories.UserRepository
+	personProvider external.PersonInfoProvider
 }
 
-func NewUserService(userRepo repositories.UserRepository, peopleAPIClient *external.PeopleAPIClient) UserService {
+func NewUserService(userRepo repositories.UserRepository, personProvider external.PersonInfoProvider) UserService {
 	return &userService{
-		userRepo:        userRepo,
-		peopleAPIClient: peopleAPIClient,
+		userRepo:       userRepo,
+		personProvider: personProvider,
 	}
 }
 
-func (s *userService) CreateUser(passportNumber string) (*models.User, error) {
-	peopleInfo, err := s.peopleAPIClient.GetPersonInfo(passportNumber)
+func (s *userService) CreateUser(ctx context.Context, passportNumber string) (_ *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "userService.CreateUser")
+	defer func() { tracing.End(span, err) }()
+
+	if passportNumber == "" {
+		return nil, ErrPassportRequired
+	}
+	if !PassportPattern.MatchString(passportNumber) {
+		return nil, ErrInvalidPassport
+	}
+
+	peopleInfo, err := s.personProvider.GetPersonInfo(ctx, passportNumber)
+	if errors.Is(err, external.ErrPersonNotFound) {
+		return nil, ErrPersonNotFound
+	}
 	if err != nil {
-		return nil, err
+		return nil, ErrPeopleAPIDown.Wrap(err)
 	}
 
 	user := &models.User{
@@ -39,22 +64,99 @@ func (s *userService) CreateUser(passportNumber string) (*models.User, error) {
 		Address:        peopleInfo.Address,
 	}
 
-	err = s.userRepo.CreateUser(user)
+	err = s.userRepo.CreateUser(ctx, user)
 	if err != nil {
-		return nil, err
+		return nil, mapRepoError(err)
 	}
 
 	return user, nil
 }
 
-func (s *userService) GetUsers(page, limit int, filters map[string]string) ([]models.User, int, error) {
-	return s.userRepo.GetUsers(page, limit, filters)
+func (s *userService) GetUsers(ctx context.Context, params models.UserListParams) (_ *models.UserList, err error) {
+	ctx, span := tracing.Start(ctx, "userService.GetUsers")
+	defer func() { tracing.End(span, err) }()
+
+	list, err := s.userRepo.GetUsers(ctx, params)
+	return list, mapRepoError(err)
+}
+
+func (s *userService) DeleteUser(ctx context.Context, userId int) (err error) {
+	ctx, span := tracing.Start(ctx, "userService.DeleteUser")
+	defer func() { tracing.End(span, err) }()
+
+	return mapRepoError(s.userRepo.DeleteUser(ctx, userId))
+}
+
+func (s *userService) RestoreUser(ctx context.Context, userId int) (err error) {
+	ctx, span := tracing.Start(ctx, "userService.RestoreUser")
+	defer func() { tracing.End(span, err) }()
+
+	err = s.userRepo.RestoreUser(ctx, userId)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		return ErrDeletedUserNotFound
+	}
+	return err
+}
+
+func (s *userService) PurgeUser(ctx context.Context, userId int) (err error) {
+	ctx, span := tracing.Start(ctx, "userService.PurgeUser")
+	defer func() { tracing.End(span, err) }()
+
+	return mapRepoError(s.userRepo.PurgeUser(ctx, userId))
 }
 
-func (s *userService) DeleteUser(userId string) error {
-	return s.userRepo.DeleteUser(userId)
+func (s *userService) UpdateUser(ctx context.Context, user *models.User) (err error) {
+	ctx, span := tracing.Start(ctx, "userService.UpdateUser")
+	defer func() { tracing.End(span, err) }()
+
+	return mapRepoError(s.userRepo.UpdateUser(ctx, user))
 }
 
-func (s *userService) UpdateUser(user *models.User) error {
-	return s.userRepo.UpdateUser(user)
+func (s *userService) PatchUser(ctx context.Context, userId int, patch models.UserPatch) (_ *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "userService.PatchUser")
+	defer func() { tracing.End(span, err) }()
+
+	user, err := s.userRepo.GetUserByID(ctx, userId)
+	if err != nil {
+		return nil, mapRepoError(err)
+	}
+
+	if patch.PassportNumber != nil && *patch.PassportNumber != user.PassportNumber {
+		if *patch.PassportNumber == "" {
+			return nil, ErrPassportRequired
+		}
+		if !PassportPattern.MatchString(*patch.PassportNumber) {
+			return nil, ErrInvalidPassport
+		}
+		owner, err := s.userRepo.GetUserByPassport(ctx, *patch.PassportNumber)
+		if err != nil && !errors.Is(err, repositories.ErrUserNotFound) {
+			return nil, err
+		}
+		if owner != nil && owner.ID != userId {
+			return nil, ErrPassportTaken
+		}
+		user.PassportNumber = *patch.PassportNumber
+	}
+	if patch.Name != nil {
+		user.Name = *patch.Name
+	}
+	if patch.Surname != nil {
+		user.Surname = *patch.Surname
+	}
+	if patch.Patronymic != nil {
+		user.Patronymic = *patch.Patronymic
+	}
+	if patch.Address != nil {
+		user.Address = *patch.Address
+	}
+	if patch.Team != nil {
+		user.Team = *patch.Team
+	}
+
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		return nil, mapRepoError(err)
+	}
+
+	user, err = s.userRepo.GetUserByID(ctx, userId)
+	return user, mapRepoError(err)
 }
@@ -0,0 +1,363 @@
+// Package testdb provides a throwaway Postgres for integration tests.
+//
+// The server is picked from, in order:
+//
+//   - TEST_DATABASE_URL, a server the tests may create databases on;
+//   - a local installation, found through PG_BIN, PATH or
+//     /usr/lib/postgresql/*/bin, started in a temporary directory;
+//   - a container of TEST_POSTGRES_IMAGE (default postgres:16-alpine) run
+//     with docker.
+//
+// Each test process gets a database of its own with the migrations applied,
+// so packages can be tested in parallel. Tests are skipped when no server is
+// available. A package using Open must run its tests through Main:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(testdb.Main(m))
+//	}
+package testdb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	postgres "github.com/KarmaBeLike/time-tracker-api/internal/database"
+	_ "github.com/lib/pq"
+)
+
+const defaultImage = "postgres:16-alpine"
+
+// startTimeout bounds how long the server may take to accept connections.
+const startTimeout = time.Minute
+
+// server is a running Postgres and the database of this process on it.
+type server struct {
+	// dsn of the maintenance database, used to create and drop the test one.
+	adminDSN string
+	dsn      string
+	db       *sql.DB
+	dbName   string
+	stop     func()
+}
+
+var (
+	once     sync.Once
+	srv      *server
+	startErr error
+)
+
+// Main runs the tests and then drops the test database and stops the server,
+// if one was started.
+func Main(m *testing.M) int {
+	code := m.Run()
+	if srv != nil {
+		srv.close()
+	}
+	return code
+}
+
+// Open returns the test database with all migrations applied, starting the
+// server on first use. It skips the test when no server is available, or
+// fails it if TEST_REQUIRE_POSTGRES=1. The data of earlier tests is still
+// there; call Reset to start clean.
+func Open(t testing.TB) *sql.DB {
+	t.Helper()
+	once.Do(func() { srv, startErr = start() })
+	if errors.Is(startErr, errUnavailable) {
+		const hint = "set TEST_DATABASE_URL, install Postgres (not usable as root) or docker"
+		if os.Getenv("TEST_REQUIRE_POSTGRES") == "1" {
+			t.Fatalf("no Postgres for integration tests, but TEST_REQUIRE_POSTGRES=1: %s", hint)
+		}
+		t.Skip("no Postgres for integration tests: " + hint)
+	}
+	if startErr != nil {
+		t.Fatalf("start test database: %v", startErr)
+	}
+	return srv.db
+}
+
+// Reset empties every table but schema_migrations and restarts the ID
+// sequences.
+func Reset(t testing.TB, db *sql.DB) {
+	t.Helper()
+	var tables sql.NullString
+	err := db.QueryRow(`
+		SELECT string_agg(quote_ident(tablename), ', ')
+		FROM pg_tables
+		WHERE schemaname = 'public' AND tablename <> 'schema_migrations'
+	`).Scan(&tables)
+	if err == nil && tables.Valid {
+		_, err = db.Exec("TRUNCATE " + tables.String + " RESTART IDENTITY CASCADE")
+	}
+	if err != nil {
+		t.Fatalf("reset test database: %v", err)
+	}
+}
+
+// AddTask creates a task and returns its ID. The API has no endpoint for
+// that.
+func AddTask(t testing.TB, db *sql.DB, name, description string) int {
+	t.Helper()
+	var id int
+	err := db.QueryRow("INSERT INTO tasks (name, description) VALUES ($1, $2) RETURNING id", name, description).Scan(&id)
+	if err != nil {
+		t.Fatalf("add task: %v", err)
+	}
+	return id
+}
+
+var errUnavailable = errors.New("no Postgres available")
+
+func start() (*server, error) {
+	var s *server
+	var err error
+	switch {
+	case os.Getenv("TEST_DATABASE_URL") != "":
+		s = &server{adminDSN: os.Getenv("TEST_DATABASE_URL"), stop: func() {}}
+	case findBinDir() != "":
+		s, err = startLocal(findBinDir())
+	default:
+		docker, lookErr := exec.LookPath("docker")
+		if lookErr != nil {
+			return nil, errUnavailable
+		}
+		s, err = startContainer(docker)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.createDatabase(); err != nil {
+		s.stop()
+		return nil, err
+	}
+	if err := migrate(s.db); err != nil {
+		s.close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// createDatabase creates the database of this process and connects to it.
+func (s *server) createDatabase() error {
+	admin, err := waitForServer(s.adminDSN)
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	s.dbName = fmt.Sprintf("timetracker_test_%d_%d", os.Getpid(), time.Now().UnixNano()%1e6)
+	if _, err := admin.Exec("CREATE DATABASE " + s.dbName); err != nil {
+		return fmt.Errorf("create test database: %w", err)
+	}
+
+	s.dsn = withDBName(s.adminDSN, s.dbName)
+	s.db, err = sql.Open("postgres", s.dsn)
+	return err
+}
+
+func (s *server) close() {
+	if s.db != nil {
+		s.db.Close()
+	}
+	if s.dbName != "" {
+		if admin, err := sql.Open("postgres", s.adminDSN); err == nil {
+			admin.Exec("DROP DATABASE IF EXISTS " + s.dbName)
+			admin.Close()
+		}
+	}
+	s.stop()
+}
+
+// withDBName points a URL or key=value DSN at another database.
+func withDBName(dsn, dbName string) string {
+	if u, err := url.Parse(dsn); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		u.Path = "/" + dbName
+		return u.String()
+	}
+	// В формате key=value последнее значение перекрывает предыдущие
+	return dsn + " dbname=" + dbName
+}
+
+// waitForServer connects to dsn, retrying until the server accepts
+// connections.
+func waitForServer(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(startTimeout)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		err = db.PingContext(ctx)
+		cancel()
+		if err == nil {
+			return db, nil
+		}
+		if time.Now().After(deadline) {
+			db.Close()
+			return nil, fmt.Errorf("postgres didn't come up: %w", err)
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+var migrateMu sync.Mutex
+
+// migrate applies the migrations, which are read relative to the module
+// root.
+func migrate(db *sql.DB) error {
+	migrateMu.Lock()
+	defer migrateMu.Unlock()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	root := wd
+	for {
+		if _, err := os.Stat(filepath.Join(root, "go.mod")); err == nil {
+			break
+		}
+		parent := filepath.Dir(root)
+		if parent == root {
+			return errors.New("module root not found")
+		}
+		root = parent
+	}
+
+	if err := os.Chdir(root); err != nil {
+		return err
+	}
+	defer os.Chdir(wd)
+	return postgres.RunMigrations(db)
+}
+
+// findBinDir returns the directory of initdb and pg_ctl, or "". initdb
+// refuses to run as root, so a local installation is skipped then.
+func findBinDir() string {
+	if os.Geteuid() == 0 {
+		return ""
+	}
+	if dir := os.Getenv("PG_BIN"); dir != "" {
+		return dir
+	}
+	if path, err := exec.LookPath("pg_ctl"); err == nil {
+		return filepath.Dir(path)
+	}
+	// Debian и Ubuntu не добавляют бинарники Postgres в PATH
+	dirs, _ := filepath.Glob("/usr/lib/postgresql/*/bin")
+	sort.Strings(dirs)
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if _, err := os.Stat(filepath.Join(dirs[i], "pg_ctl")); err == nil {
+			return dirs[i]
+		}
+	}
+	return ""
+}
+
+// startLocal initialises a cluster in a temporary directory and starts it
+// on a free port.
+func startLocal(binDir string) (*server, error) {
+	dir, err := os.MkdirTemp("", "timetracker-pg-")
+	if err != nil {
+		return nil, err
+	}
+	data := filepath.Join(dir, "data")
+
+	if err := run(filepath.Join(binDir, "initdb"), "-D", data, "-U", "postgres", "-A", "trust", "--no-sync"); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	port, err := freePort()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	opts := fmt.Sprintf("-p %d -k %s -c listen_addresses=127.0.0.1 -c fsync=off -c full_page_writes=off", port, dir)
+	pgCtl := filepath.Join(binDir, "pg_ctl")
+	if err := run(pgCtl, "-D", data, "-o", opts, "-l", filepath.Join(dir, "server.log"), "-w", "start"); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	return &server{
+		adminDSN: fmt.Sprintf("host=127.0.0.1 port=%d user=postgres dbname=postgres sslmode=disable", port),
+		stop: func() {
+			run(pgCtl, "-D", data, "-m", "immediate", "-w", "stop")
+			os.RemoveAll(dir)
+		},
+	}, nil
+}
+
+// startContainer runs Postgres in a container that is removed when stopped.
+func startContainer(docker string) (*server, error) {
+	image := os.Getenv("TEST_POSTGRES_IMAGE")
+	if image == "" {
+		image = defaultImage
+	}
+
+	out, err := output(docker, "run", "-d", "--rm",
+		"-e", "POSTGRES_PASSWORD=postgres", "-p", "127.0.0.1::5432", image, "-c", "fsync=off")
+	if err != nil {
+		return nil, err
+	}
+	id := strings.TrimSpace(out)
+	stop := func() { run(docker, "rm", "-f", id) }
+
+	out, err = output(docker, "port", id, "5432/tcp")
+	if err != nil {
+		stop()
+		return nil, err
+	}
+	// Вывод вида "127.0.0.1:49153", возможно в несколько строк
+	addr := strings.TrimSpace(strings.SplitN(out, "\n", 2)[0])
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		stop()
+		return nil, fmt.Errorf("parse container port %q: %w", out, err)
+	}
+
+	return &server{
+		adminDSN: fmt.Sprintf("host=%s port=%s user=postgres password=postgres dbname=postgres sslmode=disable", host, port),
+		stop:     stop,
+	}, nil
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+func run(name string, args ...string) error {
+	_, err := output(name, args...)
+	return err
+}
+
+func output(name string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s %s: %w: %s", filepath.Base(name), strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,6 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS users_full_name_trgm_idx ON users
+    USING GIN ((COALESCE(surname, '') || ' ' || COALESCE(name, '') || ' ' || COALESCE(patronymic, '')) gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS users_created_at_idx ON users (created_at, id);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS auto_stop_policies;
+ALTER TABLE users DROP COLUMN IF EXISTS team;
+DROP INDEX IF EXISTS worklogs_open_idx;
+ALTER TABLE worklogs DROP COLUMN IF EXISTS auto_stopped;
+ALTER TABLE worklogs DROP COLUMN IF EXISTS last_activity_at;
@@ -0,0 +1,17 @@
+ALTER TABLE worklogs ADD COLUMN IF NOT EXISTS last_activity_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE worklogs ADD COLUMN IF NOT EXISTS auto_stopped BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS worklogs_open_idx ON worklogs (start_time) WHERE end_time IS NULL;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS team VARCHAR(100);
+
+-- Политика автоостановки задаётся либо для пользователя, либо для команды
+CREATE TABLE IF NOT EXISTS auto_stop_policies (
+    id SERIAL PRIMARY KEY,
+    user_id INT UNIQUE REFERENCES users (id) ON DELETE CASCADE,
+    team VARCHAR(100) UNIQUE,
+    max_duration_seconds INT NOT NULL CHECK (max_duration_seconds > 0),
+    stop_at VARCHAR(20) NOT NULL CHECK (stop_at IN ('cap', 'last_activity')),
+    cap_seconds INT NOT NULL CHECK (cap_seconds > 0),
+    CHECK ((user_id IS NULL) <> (team IS NULL))
+);
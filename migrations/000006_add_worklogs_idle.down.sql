@@ -0,0 +1,2 @@
+ALTER TABLE worklogs DROP COLUMN IF EXISTS idle_status;
+ALTER TABLE worklogs DROP COLUMN IF EXISTS idle_since;
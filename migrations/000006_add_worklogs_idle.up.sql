@@ -0,0 +1,3 @@
+ALTER TABLE worklogs ADD COLUMN IF NOT EXISTS idle_since TIMESTAMP WITH TIME ZONE;
+ALTER TABLE worklogs ADD COLUMN IF NOT EXISTS idle_status VARCHAR(20)
+    CHECK (idle_status IN ('proposed', 'accepted', 'discarded', 'applied'));
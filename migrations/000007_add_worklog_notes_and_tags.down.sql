@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS task_tags;
+DROP TABLE IF EXISTS worklog_tags;
+DROP TABLE IF EXISTS tags;
+ALTER TABLE worklogs DROP COLUMN IF EXISTS note;
@@ -0,0 +1,25 @@
+ALTER TABLE worklogs ADD COLUMN IF NOT EXISTS note TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    color CHAR(7) NOT NULL CHECK (color ~ '^#[0-9a-f]{6}$'),
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS worklog_tags (
+    worklog_id INT NOT NULL REFERENCES worklogs (id) ON DELETE CASCADE,
+    tag_id INT NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (worklog_id, tag_id)
+);
+
+-- Задачи общие, поэтому каждый пользователь помечает их своими тегами
+CREATE TABLE IF NOT EXISTS task_tags (
+    task_id INT NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    tag_id INT NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS worklog_tags_tag_idx ON worklog_tags (tag_id);
+CREATE INDEX IF NOT EXISTS task_tags_tag_idx ON task_tags (tag_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS worklogs_search_vector_idx;
+DROP INDEX IF EXISTS tasks_search_vector_idx;
+ALTER TABLE worklogs DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE tasks DROP COLUMN IF EXISTS search_vector;
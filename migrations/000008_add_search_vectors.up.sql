@@ -0,0 +1,13 @@
+-- Конфигурация должна совпадать с searchConfig в internal/repository/search.go.
+-- simple не выделяет основы слов и не выбрасывает стоп-слова: имена и заметки
+-- бывают не только на английском
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', name), 'A') || setweight(to_tsvector('simple', description), 'B')
+    ) STORED;
+
+ALTER TABLE worklogs ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (setweight(to_tsvector('simple', note), 'B')) STORED;
+
+CREATE INDEX IF NOT EXISTS tasks_search_vector_idx ON tasks USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS worklogs_search_vector_idx ON worklogs USING GIN (search_vector);
@@ -1,9 +0,0 @@
-CREATE TABLE tasks (
-    id SERIAL PRIMARY KEY,
-    user_id INT NOT NULL,
-    hours INT NOT NULL,
-    minutes INT NOT NULL,
-    start_time TIMESTAMP WITH TIME ZONE NOT NULL,
-    end_time TIMESTAMP WITH TIME ZONE,
-    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
-);
@@ -1,11 +1,16 @@
 package logger
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"os"
 	"runtime/debug"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -24,17 +29,45 @@ func (l Level) String() string {
 	case LevelDebug:
 		return "DEBUG"
 	case LevelInfo:
-		return "Info"
+		return "INFO"
 	case LevelError:
 		return "ERROR"
 	case LevelFatal:
 		return "FATAL"
+	case LevelOff:
+		return "OFF"
 	default:
 		return ""
 
 	}
 }
 
+// ParseLevel converts a level name such as "info" or "ERROR" to a Level.
+func ParseLevel(s string) (Level, error) {
+	for level := LevelDebug; level <= LevelOff; level++ {
+		if strings.EqualFold(s, level.String()) {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q", s)
+}
+
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// Options configure a Logger. The zero value logs everything as JSON to
+// stdout without stack traces.
+type Options struct {
+	Level Level
+	// Format is FormatJSON (default) or the human-readable FormatConsole.
+	Format string
+	// Stacktrace adds the stack to error and fatal lines.
+	Stacktrace bool
+	Output     io.Writer
+}
+
 var l *Logger
 
 func init() {
@@ -42,16 +75,93 @@ func init() {
 }
 
 type Logger struct {
-	out      io.Writer
-	minLevel Level
-	mu       sync.Mutex
+	out        io.Writer
+	minLevel   *atomic.Int32
+	console    bool
+	stacktrace bool
+	fields     map[string]any
+	mu         *sync.Mutex
 }
 
 func New(out io.Writer, minLevel Level) *Logger {
-	return &Logger{
-		out:      out,
-		minLevel: minLevel,
+	return NewWithOptions(Options{Level: minLevel, Output: out, Stacktrace: true})
+}
+
+func NewWithOptions(opts Options) *Logger {
+	if opts.Output == nil {
+		opts.Output = os.Stdout
 	}
+	logger := &Logger{
+		out:        opts.Output,
+		minLevel:   &atomic.Int32{},
+		console:    opts.Format == FormatConsole,
+		stacktrace: opts.Stacktrace,
+		mu:         &sync.Mutex{},
+	}
+	logger.minLevel.Store(int32(opts.Level))
+	return logger
+}
+
+// Configure replaces the package-level logger. Loggers derived from the old
+// one with With keep writing the old way.
+func Configure(opts Options) {
+	l = NewWithOptions(opts)
+}
+
+// Default returns the package-level logger.
+func Default() *Logger {
+	return l
+}
+
+// SetLevel changes the minimum level of the package-level logger and of every
+// logger derived from it, including those of requests in flight.
+func SetLevel(level Level) {
+	l.SetLevel(level)
+}
+
+// GetLevel returns the minimum level of the package-level logger.
+func GetLevel() Level {
+	return l.Level()
+}
+
+func (l *Logger) SetLevel(level Level) {
+	l.minLevel.Store(int32(level))
+}
+
+func (l *Logger) Level() Level {
+	return Level(l.minLevel.Load())
+}
+
+// With returns a logger that adds fields to the properties of every line.
+// It writes to the same output as l and shares its level; properties of a
+// single line take precedence over fields with the same name.
+func (l *Logger) With(fields map[string]any) *Logger {
+	merged := make(map[string]any, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	child := *l
+	child.fields = merged
+	return &child
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx that carries l.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx, or the package-level logger
+// if there is none.
+func FromContext(ctx context.Context) *Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return logger
+	}
+	return l
 }
 
 func PrintDebug(message string, properties map[string]any) {
@@ -71,11 +181,34 @@ func PrintFatal(err error, properties map[string]any) {
 	os.Exit(1)
 }
 
+func (l *Logger) PrintDebug(message string, properties map[string]any) {
+	l.print(LevelDebug, message, properties)
+}
+
+func (l *Logger) PrintInfo(message string, properties map[string]any) {
+	l.print(LevelInfo, message, properties)
+}
+
+func (l *Logger) PrintError(err error, properties map[string]any) {
+	l.print(LevelError, err.Error(), properties)
+}
+
 func (l *Logger) print(level Level, message string, properties map[string]any) (int, error) {
-	if level < l.minLevel {
+	if level < l.Level() || level >= LevelOff {
 		return 0, nil
 	}
 
+	if len(l.fields) > 0 {
+		merged := make(map[string]any, len(l.fields)+len(properties))
+		for k, v := range l.fields {
+			merged[k] = v
+		}
+		for k, v := range properties {
+			merged[k] = v
+		}
+		properties = merged
+	}
+
 	aux := struct {
 		Level      string         `json:"level"`
 		Time       string         `json:"time"`
@@ -89,15 +222,20 @@ func (l *Logger) print(level Level, message string, properties map[string]any) (
 		Properties: properties,
 	}
 
-	if level >= LevelError {
+	if level >= LevelError && l.stacktrace {
 		aux.Trace = string(debug.Stack())
 	}
 
 	var line []byte
 
-	line, err := json.Marshal(aux)
-	if err != nil {
-		line = []byte(LevelError.String() + ": unable to marshal log message: " + err.Error())
+	if l.console {
+		line = consoleLine(aux.Time, aux.Level, aux.Message, aux.Properties, aux.Trace)
+	} else {
+		var err error
+		line, err = json.Marshal(aux)
+		if err != nil {
+			line = []byte(LevelError.String() + ": unable to marshal log message: " + err.Error())
+		}
 	}
 
 	l.mu.Lock()
@@ -106,6 +244,28 @@ func (l *Logger) print(level Level, message string, properties map[string]any) (
 	return l.out.Write(append(line, '\n'))
 }
 
+// consoleLine formats a line for people reading a terminal:
+// time, level, message and then the properties as sorted key=value pairs.
+func consoleLine(t, level, message string, properties map[string]any, trace string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %-5s %s", t, level, message)
+
+	keys := make([]string, 0, len(properties))
+	for k := range properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%v", k, properties[k])
+	}
+
+	if trace != "" {
+		b.WriteString("\n")
+		b.WriteString(strings.TrimRight(trace, "\n"))
+	}
+	return []byte(b.String())
+}
+
 func (l *Logger) Write(message []byte) (n int, err error) {
 	return l.print(LevelError, string(message), nil)
 }
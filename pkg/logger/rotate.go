@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RotatingFile is a log file that is rotated once it grows past maxSize
+// bytes: app.log is renamed to app.log.1, app.log.1 to app.log.2 and so on,
+// keeping at most maxBackups old files.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func NewRotatingFile(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	f := &RotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("stat log file: %w", err)
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var err error
+	switch {
+	case f.file == nil:
+		err = f.open()
+	case f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize:
+		err = f.rotate()
+	}
+	if f.file == nil {
+		return 0, err
+	}
+
+	// Если ротация не удалась, строка дописывается в прежний файл
+	n, writeErr := f.file.Write(p)
+	f.size += int64(n)
+	if writeErr != nil {
+		return n, writeErr
+	}
+	return n, err
+}
+
+// rotate moves the current file aside and opens a new one. If the file
+// can't be moved, it is opened again and grows on until the next try. The
+// file is nil only if it can't be opened at all.
+func (f *RotatingFile) rotate() error {
+	f.file.Close()
+	f.file = nil
+
+	err := f.shift()
+	if openErr := f.open(); openErr != nil {
+		return openErr
+	}
+	return err
+}
+
+func (f *RotatingFile) shift() error {
+	if f.maxBackups <= 0 {
+		if err := os.Remove(f.path); err != nil {
+			return fmt.Errorf("rotate log file: %w", err)
+		}
+		return nil
+	}
+
+	// Самый старый файл перезаписывается следующим
+	for i := f.maxBackups - 1; i > 0; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1))
+	}
+	if err := os.Rename(f.path, f.path+".1"); err != nil {
+		return fmt.Errorf("rotate log file: %w", err)
+	}
+	return nil
+}
+
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Close()
+}